@@ -0,0 +1,100 @@
+package errors
+
+import (
+	"context"
+	"strings"
+)
+
+// LogLevel is the severity at which an error should be logged.
+type LogLevel uint8
+
+const (
+	LogDebug LogLevel = iota
+	LogInfo
+	LogWarn
+	LogError
+)
+
+var logLevelStrings = [...]string{"debug", "info", "warn", "error"}
+
+func (l LogLevel) String() string {
+	if int(l) >= len(logLevelStrings) {
+		return "unknown"
+	}
+	return logLevelStrings[l]
+}
+
+// LogLevel returns LogWarn for client errors (4xx) and LogError for server errors (5xx).
+func (e *AppError) LogLevel() LogLevel {
+	return logLevelForStatus(e.HTTPStatus())
+}
+
+// LogLevel returns the level for the most severe member.
+func (l *ErrorList) LogLevel() LogLevel {
+	return logLevelForStatus(l.HTTPStatus())
+}
+
+// LogLevelOf returns the log level for any error: the ErrorList level when
+// err is one, the level of the first AppError in its chain, or LogError.
+func LogLevelOf(err error) LogLevel {
+	if list, ok := err.(*ErrorList); ok {
+		return list.LogLevel()
+	}
+	var ae *AppError
+	if As(err, &ae) {
+		return ae.LogLevel()
+	}
+	return LogError
+}
+
+func logLevelForStatus(status int) LogLevel {
+	if status >= 500 {
+		return LogError
+	}
+	return LogWarn
+}
+
+// PublicError returns a message safe to show to clients. An ErrorList joins
+// its members' public messages, otherwise the first AppError in the chain
+// yields its UserMsg and any other error a generic message. Returns "" for nil.
+func PublicError(err error) string {
+	if err == nil {
+		return ""
+	}
+	if list, ok := err.(*ErrorList); ok {
+		msgs := make([]string, len(list.Errors))
+		for i, e := range list.Errors {
+			msgs[i] = PublicError(e)
+		}
+		return strings.Join(msgs, "; ")
+	}
+	var ae *AppError
+	if As(err, &ae) && ae.UserMsg != "" {
+		return ae.UserMsg
+	}
+	return genericUserMsg
+}
+
+// IsRetryable reports whether the first AppError in err's chain is retryable.
+func IsRetryable(err error) bool {
+	var ae *AppError
+	return As(err, &ae) && ae.IsRetryable()
+}
+
+// IsTimeoutErr reports whether err's chain contains a timeout: an AppError
+// marked as timeout, context.DeadlineExceeded, or an error whose
+// Timeout() method reports true (such as net.Error).
+func IsTimeoutErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	var ae *AppError
+	if As(err, &ae) && ae.IsTimeout() {
+		return true
+	}
+	if Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var t interface{ Timeout() bool }
+	return As(err, &t) && t.Timeout()
+}
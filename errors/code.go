@@ -0,0 +1,81 @@
+package errors
+
+import "net/http"
+
+// Code is a stable, machine-readable identifier for an error condition.
+// Codes are part of the wire contract and must not change once published.
+type Code string
+
+const (
+	CodeInvalidInput Code = "INVALID_INPUT"
+	CodeValidation   Code = "VALIDATION"
+	CodeUnauthorized Code = "UNAUTHORIZED"
+	CodeForbidden    Code = "FORBIDDEN"
+	CodeNotFound     Code = "NOT_FOUND"
+	CodeConflict     Code = "CONFLICT"
+	CodeTimeout      Code = "TIMEOUT"
+	CodeRateLimited  Code = "RATE_LIMITED"
+	CodeInternal     Code = "INTERNAL"
+	CodeNetwork      Code = "NETWORK"
+)
+
+// Kind groups codes into broad categories that drive transport mapping
+// (HTTP status, log level, retry policy).
+type Kind string
+
+const (
+	KindValidation   Kind = "validation"
+	KindUnauthorized Kind = "unauthorized"
+	KindAuth         Kind = "auth"
+	KindForbidden    Kind = "forbidden"
+	KindNotFound     Kind = "not_found"
+	KindConflict     Kind = "conflict"
+	KindTimeout      Kind = "timeout"
+	KindRateLimit    Kind = "rate_limit"
+	KindInternal     Kind = "internal"
+	KindNetwork      Kind = "network"
+)
+
+var codeKinds = map[Code]Kind{
+	CodeInvalidInput: KindValidation,
+	CodeValidation:   KindValidation,
+	CodeUnauthorized: KindUnauthorized,
+	CodeForbidden:    KindForbidden,
+	CodeNotFound:     KindNotFound,
+	CodeConflict:     KindConflict,
+	CodeTimeout:      KindTimeout,
+	CodeRateLimited:  KindRateLimit,
+	CodeInternal:     KindInternal,
+	CodeNetwork:      KindNetwork,
+}
+
+var kindStatus = map[Kind]int{
+	KindValidation:   http.StatusBadRequest,
+	KindUnauthorized: http.StatusUnauthorized,
+	KindAuth:         http.StatusForbidden,
+	KindForbidden:    http.StatusForbidden,
+	KindNotFound:     http.StatusNotFound,
+	KindConflict:     http.StatusConflict,
+	KindTimeout:      http.StatusRequestTimeout,
+	KindRateLimit:    http.StatusTooManyRequests,
+	KindInternal:     http.StatusInternalServerError,
+	KindNetwork:      http.StatusInternalServerError,
+}
+
+// Kind returns the default Kind for the code.
+// Unknown codes are classified as KindInternal.
+func (c Code) Kind() Kind {
+	if k, ok := codeKinds[c]; ok {
+		return k
+	}
+	return KindInternal
+}
+
+// HTTPStatus returns the HTTP status code for the kind.
+// Unknown kinds map to 500 Internal Server Error.
+func (k Kind) HTTPStatus() int {
+	if s, ok := kindStatus[k]; ok {
+		return s
+	}
+	return http.StatusInternalServerError
+}
@@ -1,34 +1,195 @@
+// Package errors provides structured application errors with stable codes,
+// kinds, wrapping, stack traces and HTTP status mapping.
+// It interoperates with the standard library errors.Is/As/Unwrap chain.
+// Use an import alias to avoid conflict with the standard errors package, e.g.:
+//
+//	import apperr "github.com/LooneY2K/common-pkg-svc/errors"
 package errors
 
 import (
-	"net/http"
+	"time"
 )
 
+// genericUserMsg is shown to clients for errors that carry no user-safe message.
+const genericUserMsg = "an error occurred"
+
+// AppError is a structured error carrying an internal message for logs and
+// a user-safe message for clients.
 type AppError struct {
-	Message    string
-	StatusCode int
+	Code      Code
+	Kind      Kind
+	Message   string
+	UserMsg   string
+	Cause     error
+	TraceID   string
+	RequestID string
+	Retryable bool
+	Timeout   bool
+	Metadata  map[string]any
+	Timestamp time.Time
+
+	stack stack
+}
+
+// Option configures an AppError at construction.
+type Option func(*AppError)
+
+// WithCause sets the underlying error returned by Unwrap.
+func WithCause(err error) Option {
+	return func(e *AppError) {
+		e.Cause = err
+	}
+}
+
+// WithKind overrides the Kind derived from the Code.
+func WithKind(kind Kind) Option {
+	return func(e *AppError) {
+		e.Kind = kind
+	}
+}
+
+// WithTraceID sets the distributed trace ID.
+func WithTraceID(id string) Option {
+	return func(e *AppError) {
+		e.TraceID = id
+	}
+}
+
+// WithRequestID sets the request ID.
+func WithRequestID(id string) Option {
+	return func(e *AppError) {
+		e.RequestID = id
+	}
+}
+
+// WithRetryable marks whether the operation may be retried.
+func WithRetryable(retryable bool) Option {
+	return func(e *AppError) {
+		e.Retryable = retryable
+	}
+}
+
+// WithTimeout marks whether the error was caused by a timeout.
+func WithTimeout(timeout bool) Option {
+	return func(e *AppError) {
+		e.Timeout = timeout
+	}
+}
+
+// WithMetadata merges md into the error metadata.
+func WithMetadata(md map[string]any) Option {
+	return func(e *AppError) {
+		if e.Metadata == nil {
+			e.Metadata = make(map[string]any, len(md))
+		}
+		for k, v := range md {
+			e.Metadata[k] = v
+		}
+	}
+}
+
+// New creates an AppError with the given code, internal message and
+// user-facing message. If userMsg is empty, the internal message is used.
+// The Kind is derived from code unless overridden with WithKind.
+func New(code Code, internalMsg, userMsg string, opts ...Option) *AppError {
+	return newError(1, code, internalMsg, userMsg, opts...)
+}
+
+// NewFromError converts err into an AppError with the given code and user message.
+// Returns nil if err is nil, and err itself if it is already an *AppError.
+func NewFromError(err error, code Code, userMsg string, opts ...Option) *AppError {
+	if err == nil {
+		return nil
+	}
+	if ae, ok := err.(*AppError); ok {
+		return ae
+	}
+	opts = append([]Option{WithCause(err)}, opts...)
+	return newError(1, code, err.Error(), userMsg, opts...)
+}
+
+func newError(skip int, code Code, internalMsg, userMsg string, opts ...Option) *AppError {
+	if userMsg == "" {
+		userMsg = internalMsg
+	}
+	e := &AppError{
+		Code:      code,
+		Kind:      code.Kind(),
+		Message:   internalMsg,
+		UserMsg:   userMsg,
+		Timestamp: time.Now().UTC(),
+		stack:     callers(skip + 1),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Error returns the internal message. Use PublicError for clients.
+func (e *AppError) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the underlying cause.
+func (e *AppError) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether target is an *AppError with the same Code,
+// so errors.Is(err, ErrNotFound) matches any not-found error.
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+	return ok && t.Code != "" && e.Code == t.Code
+}
+
+// As lets errors.As extract the embedded *AppError from the specialized
+// error types (LimitError, RBACError, FieldError, TimeoutError).
+func (e *AppError) As(target any) bool {
+	t, ok := target.(**AppError)
+	if !ok {
+		return false
+	}
+	*t = e
+	return true
+}
+
+// HTTPStatus returns the HTTP status code for the error's Kind.
+func (e *AppError) HTTPStatus() int {
+	return e.Kind.HTTPStatus()
+}
+
+// IsRetryable reports whether the operation may be retried.
+func (e *AppError) IsRetryable() bool {
+	return e.Retryable
+}
+
+// IsTimeout reports whether the error was caused by a timeout.
+func (e *AppError) IsTimeout() bool {
+	return e.Timeout
 }
 
-func (err *AppError) Error() string {
-	return err.Message
+// StackTrace returns the call stack captured when the error was created.
+func (e *AppError) StackTrace() StackTrace {
+	return e.stack.trace()
 }
 
 func BadRequest(message string) *AppError {
-	return &AppError{message, http.StatusBadRequest}
+	return newError(1, CodeInvalidInput, message, message)
 }
 
 func InternalServerError(message string) *AppError {
-	return &AppError{message, http.StatusInternalServerError}
+	return newError(1, CodeInternal, message, message)
 }
 
 func NotFound(message string) *AppError {
-	return &AppError{message, http.StatusNotFound}
+	return newError(1, CodeNotFound, message, message)
 }
 
 func Unauthorized(message string) *AppError {
-	return &AppError{message, http.StatusUnauthorized}
+	return newError(1, CodeUnauthorized, message, message)
 }
 
 func Forbidden(message string) *AppError {
-	return &AppError{message, http.StatusForbidden}
+	return newError(1, CodeForbidden, message, message)
 }
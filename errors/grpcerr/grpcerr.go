@@ -0,0 +1,246 @@
+// Package grpcerr maps AppErrors to and from gRPC statuses so a single error
+// can flow from a gRPC backend through an HTTP gateway with the right status.
+//
+// The AppError Code travels as the Reason of an errdetails.ErrorInfo detail
+// together with the (redacted) metadata; request and trace IDs travel in an
+// errdetails.RequestInfo detail, and retryable errors carry an
+// errdetails.RetryInfo detail.
+package grpcerr
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	apperr "github.com/LooneY2K/common-pkg-svc/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Domain is the ErrorInfo domain attached to statuses built by this package.
+const Domain = "common-pkg-svc"
+
+var kindCodes = map[apperr.Kind]codes.Code{
+	apperr.KindValidation:   codes.InvalidArgument,
+	apperr.KindUnauthorized: codes.Unauthenticated,
+	apperr.KindAuth:         codes.PermissionDenied,
+	apperr.KindForbidden:    codes.PermissionDenied,
+	apperr.KindNotFound:     codes.NotFound,
+	apperr.KindConflict:     codes.AlreadyExists,
+	apperr.KindTimeout:      codes.DeadlineExceeded,
+	apperr.KindRateLimit:    codes.ResourceExhausted,
+	apperr.KindInternal:     codes.Internal,
+	apperr.KindNetwork:      codes.Unavailable,
+}
+
+var grpcKinds = map[codes.Code]apperr.Kind{
+	codes.InvalidArgument:    apperr.KindValidation,
+	codes.OutOfRange:         apperr.KindValidation,
+	codes.FailedPrecondition: apperr.KindValidation,
+	codes.Unauthenticated:    apperr.KindUnauthorized,
+	codes.PermissionDenied:   apperr.KindForbidden,
+	codes.NotFound:           apperr.KindNotFound,
+	codes.AlreadyExists:      apperr.KindConflict,
+	codes.Aborted:            apperr.KindConflict,
+	codes.DeadlineExceeded:   apperr.KindTimeout,
+	codes.ResourceExhausted:  apperr.KindRateLimit,
+	codes.Unavailable:        apperr.KindNetwork,
+}
+
+var kindAppCodes = map[apperr.Kind]apperr.Code{
+	apperr.KindValidation:   apperr.CodeInvalidInput,
+	apperr.KindUnauthorized: apperr.CodeUnauthorized,
+	apperr.KindAuth:         apperr.CodeForbidden,
+	apperr.KindForbidden:    apperr.CodeForbidden,
+	apperr.KindNotFound:     apperr.CodeNotFound,
+	apperr.KindConflict:     apperr.CodeConflict,
+	apperr.KindTimeout:      apperr.CodeTimeout,
+	apperr.KindRateLimit:    apperr.CodeRateLimited,
+	apperr.KindInternal:     apperr.CodeInternal,
+	apperr.KindNetwork:      apperr.CodeNetwork,
+}
+
+// CodeOf returns the gRPC code for kind. Unknown kinds map to codes.Internal.
+func CodeOf(kind apperr.Kind) codes.Code {
+	if c, ok := kindCodes[kind]; ok {
+		return c
+	}
+	return codes.Internal
+}
+
+// KindOf returns the Kind for a gRPC code. Unmapped codes are KindInternal.
+func KindOf(code codes.Code) apperr.Kind {
+	if k, ok := grpcKinds[code]; ok {
+		return k
+	}
+	return apperr.KindInternal
+}
+
+// ToStatus converts err into a gRPC status. AppErrors keep their Code,
+// user message and metadata; context errors map to DeadlineExceeded and
+// Canceled; existing statuses pass through; anything else becomes
+// codes.Unknown with a generic message. Returns nil for a nil error.
+func ToStatus(err error) *status.Status {
+	if err == nil {
+		return nil
+	}
+	var ae *apperr.AppError
+	if !apperr.As(err, &ae) {
+		if st, ok := status.FromError(err); ok {
+			return st
+		}
+		switch {
+		case apperr.Is(err, context.DeadlineExceeded):
+			return status.New(codes.DeadlineExceeded, apperr.PublicError(err))
+		case apperr.Is(err, context.Canceled):
+			return status.New(codes.Canceled, apperr.PublicError(err))
+		}
+		return status.New(codes.Unknown, apperr.PublicError(err))
+	}
+
+	st := status.New(CodeOf(ae.Kind), apperr.PublicError(ae))
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{
+		Reason:   string(ae.Code),
+		Domain:   Domain,
+		Metadata: stringMetadata(apperr.Redact(ae.Metadata)),
+	}}
+	if ae.RequestID != "" || ae.TraceID != "" {
+		details = append(details, &errdetails.RequestInfo{
+			RequestId:   ae.RequestID,
+			ServingData: ae.TraceID,
+		})
+	}
+	if ae.Retryable {
+		info := &errdetails.RetryInfo{}
+		var le *apperr.LimitError
+		if apperr.As(err, &le) {
+			info.RetryDelay = durationpb.New(le.RetryAfter())
+		}
+		details = append(details, info)
+	}
+	withDetails, detailErr := st.WithDetails(details...)
+	if detailErr != nil {
+		return st
+	}
+	return withDetails
+}
+
+// FromStatus rebuilds an AppError from a gRPC status. The Code comes from
+// the ErrorInfo reason when it was set by this package and from the gRPC
+// code otherwise.
+// Returns nil for a nil or OK status.
+func FromStatus(st *status.Status) *apperr.AppError {
+	if st == nil || st.Code() == codes.OK {
+		return nil
+	}
+	kind := KindOf(st.Code())
+	code := kindAppCodes[kind]
+	var opts []apperr.Option
+	for _, d := range st.Details() {
+		switch info := d.(type) {
+		case *errdetails.ErrorInfo:
+			if info.GetDomain() == Domain && info.GetReason() != "" {
+				code = apperr.Code(info.GetReason())
+				kind = code.Kind()
+			}
+			if md := info.GetMetadata(); len(md) > 0 {
+				m := make(map[string]any, len(md))
+				for k, v := range md {
+					m[k] = v
+				}
+				opts = append(opts, apperr.WithMetadata(m))
+			}
+		case *errdetails.RequestInfo:
+			opts = append(opts,
+				apperr.WithRequestID(info.GetRequestId()),
+				apperr.WithTraceID(info.GetServingData()),
+			)
+		case *errdetails.RetryInfo:
+			opts = append(opts, apperr.WithRetryable(true))
+		}
+	}
+	opts = append(opts,
+		apperr.WithKind(kind),
+		apperr.WithTimeout(kind == apperr.KindTimeout),
+		apperr.WithCause(st.Err()),
+	)
+	return apperr.New(code, st.Message(), st.Message(), opts...)
+}
+
+// FromError converts an error returned by a gRPC client call into an
+// AppError. Errors that do not carry a status become CodeInternal.
+// Returns nil for a nil error.
+func FromError(err error) *apperr.AppError {
+	if err == nil {
+		return nil
+	}
+	var ae *apperr.AppError
+	if apperr.As(err, &ae) {
+		return ae
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return apperr.NewFromError(err, apperr.CodeInternal, "")
+	}
+	return FromStatus(st)
+}
+
+// UnaryServerInterceptor converts errors returned by unary handlers into
+// gRPC statuses with ToStatus.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, ToStatus(err).Err()
+		}
+		return resp, nil
+	}
+}
+
+// StreamServerInterceptor converts errors returned by stream handlers into
+// gRPC statuses with ToStatus.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := handler(srv, ss); err != nil {
+			return ToStatus(err).Err()
+		}
+		return nil
+	}
+}
+
+// UnaryClientInterceptor converts status errors returned by unary calls
+// into AppErrors with FromError.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+			return FromError(err)
+		}
+		return nil
+	}
+}
+
+func stringMetadata(md map[string]any) map[string]string {
+	if len(md) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(md))
+	for k, v := range md {
+		switch val := v.(type) {
+		case string:
+			out[k] = val
+		case time.Time:
+			out[k] = val.UTC().Format(time.RFC3339)
+		default:
+			b, err := json.Marshal(val)
+			if err != nil {
+				continue
+			}
+			out[k] = string(b)
+		}
+	}
+	return out
+}
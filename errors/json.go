@@ -0,0 +1,182 @@
+package errors
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Redacted replaces the value of sensitive metadata keys when an AppError
+// is marshaled to JSON.
+const Redacted = "[REDACTED]"
+
+var defaultRedactKeys = []string{
+	"password", "passwd", "secret", "token", "access_token", "refresh_token",
+	"api_key", "apikey", "authorization", "cookie", "email", "phone", "ssn",
+	"credit_card", "card_number", "cvv",
+}
+
+var (
+	redactMu   sync.RWMutex
+	redactKeys = keySet(defaultRedactKeys)
+)
+
+// SetRedactKeys replaces the set of metadata keys whose values are redacted
+// on marshal. Keys are matched case-insensitively at any nesting depth.
+func SetRedactKeys(keys ...string) {
+	redactMu.Lock()
+	defer redactMu.Unlock()
+	redactKeys = keySet(keys)
+}
+
+// AddRedactKeys adds keys to the set of redacted metadata keys.
+func AddRedactKeys(keys ...string) {
+	redactMu.Lock()
+	defer redactMu.Unlock()
+	for _, k := range keys {
+		redactKeys[strings.ToLower(k)] = struct{}{}
+	}
+}
+
+// RedactKeys returns the current set of redacted metadata keys.
+func RedactKeys() []string {
+	redactMu.RLock()
+	defer redactMu.RUnlock()
+	out := make([]string, 0, len(redactKeys))
+	for k := range redactKeys {
+		out = append(out, k)
+	}
+	return out
+}
+
+// Redact returns a copy of md with sensitive values replaced by Redacted,
+// descending into nested maps, slices and structs. Nested values other
+// than map[string]any and []any come back in their JSON form.
+func Redact(md map[string]any) map[string]any {
+	if md == nil {
+		return nil
+	}
+	redactMu.RLock()
+	defer redactMu.RUnlock()
+	return redactMap(md)
+}
+
+// wireError is the JSON representation of an AppError.
+// Only the user-safe message crosses the wire; the internal message,
+// cause and stack trace stay in the process.
+type wireError struct {
+	Code      Code           `json:"code"`
+	Kind      Kind           `json:"kind"`
+	Message   string         `json:"message"`
+	TraceID   string         `json:"trace_id,omitempty"`
+	RequestID string         `json:"request_id,omitempty"`
+	Retryable bool           `json:"retryable,omitempty"`
+	Timeout   bool           `json:"timeout,omitempty"`
+	Timestamp *time.Time     `json:"timestamp,omitempty"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+}
+
+// MarshalJSON encodes the error in its wire form with PII redacted from metadata.
+func (e *AppError) MarshalJSON() ([]byte, error) {
+	w := wireError{
+		Code:      e.Code,
+		Kind:      e.Kind,
+		Message:   e.UserMsg,
+		TraceID:   e.TraceID,
+		RequestID: e.RequestID,
+		Retryable: e.Retryable,
+		Timeout:   e.Timeout,
+		Metadata:  Redact(e.Metadata),
+	}
+	if !e.Timestamp.IsZero() {
+		ts := e.Timestamp
+		w.Timestamp = &ts
+	}
+	return json.Marshal(w)
+}
+
+// UnmarshalJSON rebuilds an AppError from its wire form.
+// The wire message becomes both UserMsg and Message; Kind is derived
+// from Code when absent.
+func (e *AppError) UnmarshalJSON(data []byte) error {
+	var w wireError
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+	*e = AppError{
+		Code:      w.Code,
+		Kind:      w.Kind,
+		Message:   w.Message,
+		UserMsg:   w.Message,
+		TraceID:   w.TraceID,
+		RequestID: w.RequestID,
+		Retryable: w.Retryable,
+		Timeout:   w.Timeout,
+		Metadata:  w.Metadata,
+	}
+	if e.Kind == "" {
+		e.Kind = e.Code.Kind()
+	}
+	if w.Timestamp != nil {
+		e.Timestamp = *w.Timestamp
+	}
+	return nil
+}
+
+func redactMap(m map[string]any) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		if _, ok := redactKeys[strings.ToLower(k)]; ok {
+			out[k] = Redacted
+			continue
+		}
+		out[k] = redactValue(v)
+	}
+	return out
+}
+
+func redactValue(v any) any {
+	switch val := v.(type) {
+	case nil, string, bool, int, int64, float64, time.Time:
+		return v
+	case map[string]any:
+		return redactMap(val)
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = redactValue(item)
+		}
+		return out
+	default:
+		return redactOther(v)
+	}
+}
+
+// redactOther normalises typed maps, slices and structs through JSON so
+// their keys can be redacted like any other metadata. Values that do not
+// encode to an object or array are returned unchanged.
+func redactOther(v any) any {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var generic any
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return v
+	}
+	switch generic.(type) {
+	case map[string]any, []any:
+		return redactValue(generic)
+	default:
+		return v
+	}
+}
+
+func keySet(keys []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		set[strings.ToLower(k)] = struct{}{}
+	}
+	return set
+}
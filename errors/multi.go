@@ -0,0 +1,85 @@
+package errors
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ErrorList aggregates several errors. It satisfies errors.Is and errors.As
+// for every member through Unwrap() []error.
+type ErrorList struct {
+	Errors []error
+}
+
+// MultiError combines errs into a single error. Nil errors are dropped and
+// nested ErrorLists are flattened. Returns nil when no errors remain and the
+// error itself, unchanged, when exactly one remains.
+func MultiError(errs ...error) error {
+	var out []error
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if l, ok := err.(*ErrorList); ok {
+			out = append(out, l.Errors...)
+			continue
+		}
+		out = append(out, err)
+	}
+	switch len(out) {
+	case 0:
+		return nil
+	case 1:
+		return out[0]
+	default:
+		return &ErrorList{Errors: out}
+	}
+}
+
+// Error joins the member messages with "; ".
+func (l *ErrorList) Error() string {
+	msgs := make([]string, len(l.Errors))
+	for i, err := range l.Errors {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the member errors.
+func (l *ErrorList) Unwrap() []error {
+	return l.Errors
+}
+
+// AppErrors returns every member as an *AppError. Members that are not
+// AppErrors are converted with CodeInternal and a generic user message.
+func (l *ErrorList) AppErrors() []*AppError {
+	out := make([]*AppError, 0, len(l.Errors))
+	for _, err := range l.Errors {
+		var ae *AppError
+		if !As(err, &ae) {
+			ae = newError(1, CodeInternal, err.Error(), genericUserMsg, WithCause(err))
+		}
+		out = append(out, ae)
+	}
+	return out
+}
+
+// HTTPStatus returns the most severe (highest) status among the members.
+// Members that are not AppErrors count as 500.
+func (l *ErrorList) HTTPStatus() int {
+	status := 0
+	for _, err := range l.Errors {
+		s := http.StatusInternalServerError
+		var ae *AppError
+		if As(err, &ae) {
+			s = ae.HTTPStatus()
+		}
+		if s > status {
+			status = s
+		}
+	}
+	if status == 0 {
+		return http.StatusInternalServerError
+	}
+	return status
+}
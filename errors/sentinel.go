@@ -0,0 +1,30 @@
+package errors
+
+// Sentinel errors for use with errors.Is. Matching is by Code, so any
+// AppError with the same Code satisfies errors.Is against its sentinel.
+// Sentinels are shared values and must not be mutated.
+var (
+	ErrInvalidInput = sentinel(CodeInvalidInput, "invalid input")
+	ErrNotFound     = sentinel(CodeNotFound, "resource not found")
+	ErrConflict     = sentinel(CodeConflict, "resource conflict")
+	ErrTimeout      = sentinel(CodeTimeout, "operation timed out", WithTimeout(true), WithRetryable(true))
+	ErrRateLimited  = sentinel(CodeRateLimited, "rate limit exceeded", WithRetryable(true))
+	ErrForbidden    = sentinel(CodeForbidden, "forbidden")
+	ErrUnauthorized = sentinel(CodeUnauthorized, "unauthorized")
+	ErrInternal     = sentinel(CodeInternal, "internal error")
+	ErrNetwork      = sentinel(CodeNetwork, "network error", WithRetryable(true))
+)
+
+// sentinel builds a package-level error without a stack trace or timestamp.
+func sentinel(code Code, msg string, opts ...Option) *AppError {
+	e := &AppError{
+		Code:    code,
+		Kind:    code.Kind(),
+		Message: msg,
+		UserMsg: msg,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
@@ -0,0 +1,136 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+const maxStackDepth = 32
+
+var captureStack atomic.Bool
+
+func init() {
+	captureStack.Store(true)
+}
+
+// SetStackCapture enables or disables stack capture for newly created errors.
+// Capture is enabled by default; disable it in hot paths where the cost of
+// runtime.Callers matters more than the trace.
+func SetStackCapture(enabled bool) {
+	captureStack.Store(enabled)
+}
+
+// StackCaptureEnabled reports whether new errors capture a stack trace.
+func StackCaptureEnabled() bool {
+	return captureStack.Load()
+}
+
+// Frame is a single call site in a captured stack trace.
+type Frame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+// String formats the frame as "function\n\tfile:line".
+func (f Frame) String() string {
+	return f.Function + "\n\t" + f.File + ":" + strconv.Itoa(f.Line)
+}
+
+// StackTrace is the call stack recorded when an AppError was created,
+// innermost frame first.
+type StackTrace []Frame
+
+// String formats the trace one frame per entry, innermost first.
+func (st StackTrace) String() string {
+	var b strings.Builder
+	for i, f := range st {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(f.String())
+	}
+	return b.String()
+}
+
+// StackTraceOf returns the stack trace of the first AppError in err's chain
+// that has one, or nil.
+func StackTraceOf(err error) StackTrace {
+	var st StackTrace
+	walk(err, func(e error) {
+		if st != nil {
+			return
+		}
+		var ae *AppError
+		if As(e, &ae) && len(ae.stack) > 0 {
+			st = ae.stack.trace()
+		}
+	})
+	return st
+}
+
+type stack []uintptr
+
+// callers records the program counters of the calling goroutine,
+// skipping skip frames above its caller. Returns nil when capture is disabled.
+func callers(skip int) stack {
+	if !captureStack.Load() {
+		return nil
+	}
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(skip+2, pcs)
+	return pcs[:n]
+}
+
+func (s stack) trace() StackTrace {
+	if len(s) == 0 {
+		return nil
+	}
+	frames := runtime.CallersFrames(s)
+	st := make(StackTrace, 0, len(s))
+	for {
+		f, more := frames.Next()
+		st = append(st, Frame{Function: f.Function, File: f.File, Line: f.Line})
+		if !more {
+			break
+		}
+	}
+	return st
+}
+
+// Format implements fmt.Formatter. %s and %v print the internal message,
+// %q prints it quoted, and %+v also prints the cause and the stack trace.
+func (e *AppError) Format(s fmt.State, verb rune) {
+	formatError(s, verb, e, e.Cause)
+}
+
+// Format implements fmt.Formatter with the same verbs as AppError.
+func (w *wrapError) Format(s fmt.State, verb rune) {
+	formatError(s, verb, w, nil)
+}
+
+func formatError(s fmt.State, verb rune, err error, cause error) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			io.WriteString(s, err.Error())
+			if cause != nil {
+				fmt.Fprintf(s, "\ncaused by: %v", cause)
+			}
+			if st := StackTraceOf(err); st != nil {
+				io.WriteString(s, "\n")
+				io.WriteString(s, st.String())
+			}
+			return
+		}
+		io.WriteString(s, err.Error())
+	case 's':
+		io.WriteString(s, err.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", err.Error())
+	}
+}
@@ -0,0 +1,136 @@
+package errors
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// LimitError reports that a rate limit was exceeded.
+// Use Headers to populate Retry-After and X-RateLimit-* response headers.
+type LimitError struct {
+	AppError
+	Remaining int
+	Reset     time.Time
+}
+
+// NewLimitError creates a retryable rate-limit error with the remaining
+// quota and the time at which the limit resets.
+func NewLimitError(remaining int, reset time.Time, opts ...Option) *LimitError {
+	opts = append([]Option{
+		WithRetryable(true),
+		WithMetadata(map[string]any{"remaining": remaining, "reset": reset.UTC().Format(time.RFC3339)}),
+	}, opts...)
+	return &LimitError{
+		AppError:  *newError(1, CodeRateLimited, "rate limit exceeded", "", opts...),
+		Remaining: remaining,
+		Reset:     reset,
+	}
+}
+
+// RetryAfter returns the time until the limit resets, never negative.
+func (e *LimitError) RetryAfter() time.Duration {
+	d := time.Until(e.Reset)
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// Headers returns the Retry-After and X-RateLimit-* headers for the error.
+func (e *LimitError) Headers() http.Header {
+	h := make(http.Header, 3)
+	secs := int64(e.RetryAfter().Round(time.Second) / time.Second)
+	h.Set("Retry-After", strconv.FormatInt(secs, 10))
+	h.Set("X-RateLimit-Remaining", strconv.Itoa(e.Remaining))
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(e.Reset.Unix(), 10))
+	return h
+}
+
+// RBACError reports that a role is not permitted to perform an action on a resource.
+type RBACError struct {
+	AppError
+	Role     string
+	Action   string
+	Resource string
+}
+
+// NewRBACError creates a forbidden error for role performing action on resource.
+// The internal message and typed fields name all three; the user message
+// and metadata, which reach clients, do not.
+func NewRBACError(role, action, resource string, opts ...Option) *RBACError {
+	msg := fmt.Sprintf("role %q is not allowed to %s %s", role, action, resource)
+	return &RBACError{
+		AppError: *newError(1, CodeForbidden, msg, "you do not have permission to perform this action", opts...),
+		Role:     role,
+		Action:   action,
+		Resource: resource,
+	}
+}
+
+// FieldError reports a validation failure on a single input field.
+type FieldError struct {
+	AppError
+	Field  string
+	Reason string
+}
+
+// FieldErrorf creates a validation error for field with a formatted reason.
+func FieldErrorf(field, format string, args ...any) *FieldError {
+	reason := fmt.Sprintf(format, args...)
+	msg := field + ": " + reason
+	return &FieldError{
+		AppError: *newError(1, CodeValidation, msg, msg, WithMetadata(map[string]any{"field": field})),
+		Field:    field,
+		Reason:   reason,
+	}
+}
+
+// FieldErrors collects every FieldError in err's chain, including joined
+// errors, into a field → reason map. Returns nil if there are none.
+func FieldErrors(err error) map[string]string {
+	var out map[string]string
+	walk(err, func(e error) {
+		if fe, ok := e.(*FieldError); ok {
+			if out == nil {
+				out = make(map[string]string)
+			}
+			out[fe.Field] = fe.Reason
+		}
+	})
+	return out
+}
+
+// TimeoutError reports that an operation did not complete before its deadline.
+type TimeoutError struct {
+	AppError
+	Deadline time.Time
+}
+
+// NewTimeoutError creates a retryable timeout error for the given deadline.
+func NewTimeoutError(deadline time.Time, opts ...Option) *TimeoutError {
+	msg := "deadline exceeded at " + deadline.UTC().Format(time.RFC3339)
+	opts = append([]Option{WithTimeout(true), WithRetryable(true)}, opts...)
+	return &TimeoutError{
+		AppError: *newError(1, CodeTimeout, msg, "the operation timed out", opts...),
+		Deadline: deadline,
+	}
+}
+
+// walk calls fn for err and every error reachable through Unwrap,
+// following both single and multi-error chains depth first.
+func walk(err error, fn func(error)) {
+	if err == nil {
+		return
+	}
+	fn(err)
+	switch u := err.(type) {
+	case interface{ Unwrap() []error }:
+		for _, e := range u.Unwrap() {
+			walk(e, fn)
+		}
+	case interface{ Unwrap() error }:
+		walk(u.Unwrap(), fn)
+	}
+}
@@ -0,0 +1,66 @@
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+)
+
+// Is reports whether any error in err's chain matches target.
+// It is a passthrough to the standard library errors.Is.
+func Is(err, target error) bool {
+	return stderrors.Is(err, target)
+}
+
+// As finds the first error in err's chain that matches target.
+// It is a passthrough to the standard library errors.As.
+func As(err error, target any) bool {
+	return stderrors.As(err, target)
+}
+
+// Unwrap returns the result of calling Unwrap on err, if any.
+// It is a passthrough to the standard library errors.Unwrap.
+func Unwrap(err error) error {
+	return stderrors.Unwrap(err)
+}
+
+type wrapError struct {
+	msg string
+	err error
+}
+
+func (w *wrapError) Error() string {
+	return w.msg + ": " + w.err.Error()
+}
+
+func (w *wrapError) Unwrap() error {
+	return w.err
+}
+
+// Wrap annotates err with msg while preserving it for errors.Is/As.
+// Returns nil if err is nil.
+func Wrap(err error, msg string) error {
+	if err == nil {
+		return nil
+	}
+	return &wrapError{msg: msg, err: err}
+}
+
+// Wrapf is like Wrap with a formatted message.
+func Wrapf(err error, format string, args ...any) error {
+	if err == nil {
+		return nil
+	}
+	return &wrapError{msg: fmt.Sprintf(format, args...), err: err}
+}
+
+// RootCause returns the innermost error in err's Unwrap chain.
+func RootCause(err error) error {
+	for err != nil {
+		next := stderrors.Unwrap(err)
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+	return err
+}
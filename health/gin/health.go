@@ -0,0 +1,14 @@
+// Package gin mounts health endpoints on gin engines.
+package gin
+
+import (
+	"github.com/LooneY2K/common-pkg-svc/health"
+	"github.com/gin-gonic/gin"
+)
+
+// Mount registers /healthz, /readyz and /livez on a gin router.
+func Mount(router gin.IRouter, reg *health.Registry) {
+	router.GET(health.HealthPath, gin.WrapH(reg.HealthHandler()))
+	router.GET(health.ReadinessPath, gin.WrapH(reg.ReadinessHandler()))
+	router.GET(health.LivenessPath, gin.WrapH(reg.LivenessHandler()))
+}
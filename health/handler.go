@@ -0,0 +1,53 @@
+package health
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/LooneY2K/common-pkg-svc/respond"
+	"github.com/go-chi/chi/v5"
+)
+
+// Paths of the endpoints registered by Mount.
+const (
+	HealthPath    = "/healthz"
+	ReadinessPath = "/readyz"
+	LivenessPath  = "/livez"
+)
+
+// HealthHandler serves Health.
+func (r *Registry) HealthHandler() http.Handler {
+	return reportHandler(r.Health)
+}
+
+// ReadinessHandler serves Readiness.
+func (r *Registry) ReadinessHandler() http.Handler {
+	return reportHandler(r.Readiness)
+}
+
+// LivenessHandler serves Liveness.
+func (r *Registry) LivenessHandler() http.Handler {
+	return reportHandler(r.Liveness)
+}
+
+// Mount registers /healthz, /readyz and /livez on a chi router.
+func Mount(router chi.Router, reg *Registry) {
+	router.Method(http.MethodGet, HealthPath, reg.HealthHandler())
+	router.Method(http.MethodGet, ReadinessPath, reg.ReadinessHandler())
+	router.Method(http.MethodGet, LivenessPath, reg.LivenessHandler())
+}
+
+// reportHandler writes the report in the respond envelope: 200 when up or
+// degraded, 503 when down, with per-check detail under "data".
+func reportHandler(run func(context.Context) Report) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		report := run(r.Context())
+		response := respond.Response{Status: http.StatusOK, Data: report}
+		if report.Status == StatusDown {
+			response.Status = http.StatusServiceUnavailable
+			response.Error = true
+			response.Message = http.StatusText(http.StatusServiceUnavailable)
+		}
+		respond.NewWriter(rw).Header("Cache-Control", "no-store").Respond(response)
+	})
+}
@@ -0,0 +1,242 @@
+// Package health runs dependency checks for health, readiness and
+// liveness endpoints.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTimeout bounds a check registered without WithTimeout.
+const DefaultTimeout = 5 * time.Second
+
+// Checker checks a single dependency. Check returns nil when it is healthy.
+type Checker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+type checkFunc struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+func (c checkFunc) Name() string                    { return c.name }
+func (c checkFunc) Check(ctx context.Context) error { return c.fn(ctx) }
+
+// CheckFunc returns a Checker named name that calls fn.
+func CheckFunc(name string, fn func(ctx context.Context) error) Checker {
+	return checkFunc{name: name, fn: fn}
+}
+
+// Status is the outcome of a check or of a whole report.
+type Status string
+
+const (
+	// StatusUp means every check passed.
+	StatusUp Status = "up"
+	// StatusDegraded means only non-critical checks failed.
+	StatusDegraded Status = "degraded"
+	// StatusDown means a critical check failed or the service is shutting down.
+	StatusDown Status = "down"
+)
+
+// Result is the outcome of one check.
+type Result struct {
+	Status    Status        `json:"status"`
+	Critical  bool          `json:"critical"`
+	Error     string        `json:"error,omitempty"`
+	Duration  time.Duration `json:"duration_ns"`
+	CheckedAt time.Time     `json:"checked_at"`
+}
+
+// Report is the outcome of a set of checks, keyed by check name.
+type Report struct {
+	Status Status            `json:"status"`
+	Reason string            `json:"reason,omitempty"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+// Option configures a Registry.
+type Option func(*Registry)
+
+// WithCacheTTL reuses each check's result for ttl, so frequent probes do not
+// hammer dependencies. The default 0 runs checks on every request.
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(r *Registry) {
+		r.ttl = ttl
+	}
+}
+
+// CheckOption configures a registered check.
+type CheckOption func(*check)
+
+// WithTimeout bounds each run of the check (default DefaultTimeout).
+func WithTimeout(d time.Duration) CheckOption {
+	return func(c *check) {
+		c.timeout = d
+	}
+}
+
+// NonCritical makes a failing check degrade the report instead of taking
+// it down.
+func NonCritical() CheckOption {
+	return func(c *check) {
+		c.critical = false
+	}
+}
+
+type check struct {
+	checker  Checker
+	timeout  time.Duration
+	critical bool
+
+	mu     sync.Mutex
+	result Result
+}
+
+// Registry holds the checks of a service and runs them concurrently.
+// It is safe for concurrent use.
+type Registry struct {
+	ttl time.Duration
+
+	mu        sync.RWMutex
+	readiness []*check
+	liveness  []*check
+	ready     atomic.Bool
+}
+
+// NewRegistry returns an empty Registry that reports ready.
+func NewRegistry(opts ...Option) *Registry {
+	r := &Registry{}
+	r.ready.Store(true)
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Register adds a readiness check: a dependency the service needs to serve
+// traffic, such as a database. Checks are critical unless NonCritical.
+func (r *Registry) Register(c Checker, opts ...CheckOption) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.readiness = append(r.readiness, newCheck(c, opts))
+}
+
+// RegisterLiveness adds a liveness check: a condition that only a restart
+// can fix, such as a deadlocked worker.
+func (r *Registry) RegisterLiveness(c Checker, opts ...CheckOption) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.liveness = append(r.liveness, newCheck(c, opts))
+}
+
+func newCheck(c Checker, opts []CheckOption) *check {
+	chk := &check{checker: c, timeout: DefaultTimeout, critical: true}
+	for _, opt := range opts {
+		opt(chk)
+	}
+	return chk
+}
+
+// SetReady sets whether the service accepts traffic. While not ready,
+// Readiness reports down without running checks.
+func (r *Registry) SetReady(ready bool) {
+	r.ready.Store(ready)
+}
+
+// Ready reports the value last set with SetReady.
+func (r *Registry) Ready() bool {
+	return r.ready.Load()
+}
+
+// Health runs every check.
+func (r *Registry) Health(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := append(append([]*check(nil), r.readiness...), r.liveness...)
+	r.mu.RUnlock()
+	return r.run(ctx, checks)
+}
+
+// Readiness runs the readiness checks, or reports down once SetReady(false)
+// was called, for example when shutdown begins.
+func (r *Registry) Readiness(ctx context.Context) Report {
+	if !r.Ready() {
+		return Report{Status: StatusDown, Reason: "shutting down"}
+	}
+	r.mu.RLock()
+	checks := append([]*check(nil), r.readiness...)
+	r.mu.RUnlock()
+	return r.run(ctx, checks)
+}
+
+// Liveness runs the liveness checks.
+func (r *Registry) Liveness(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := append([]*check(nil), r.liveness...)
+	r.mu.RUnlock()
+	return r.run(ctx, checks)
+}
+
+func (r *Registry) run(ctx context.Context, checks []*check) Report {
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Go(func() { results[i] = r.runCheck(ctx, c) })
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp}
+	if len(checks) > 0 {
+		report.Checks = make(map[string]Result, len(checks))
+	}
+	for i, c := range checks {
+		res := results[i]
+		report.Checks[c.checker.Name()] = res
+		switch {
+		case res.Status == StatusUp:
+		case res.Critical:
+			report.Status = StatusDown
+		case report.Status == StatusUp:
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// runCheck returns the cached result of c while it is fresh and runs c
+// otherwise. Concurrent callers of the same check wait for one run. Results
+// cut short by the caller's context are not cached.
+func (r *Registry) runCheck(ctx context.Context, c *check) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if r.ttl > 0 && !c.result.CheckedAt.IsZero() && time.Since(c.result.CheckedAt) < r.ttl {
+		return c.result
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	start := time.Now()
+	errc := make(chan error, 1)
+	go func() { errc <- c.checker.Check(ctx) }()
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	res := Result{Status: StatusUp, Critical: c.critical, Duration: time.Since(start), CheckedAt: start}
+	if err != nil {
+		res.Status = StatusDown
+		res.Error = err.Error()
+	}
+	if parent.Err() == nil {
+		c.result = res
+	}
+	return res
+}
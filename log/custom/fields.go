@@ -1,6 +1,10 @@
 package elog
 
-import "time"
+import (
+	"time"
+
+	apperr "github.com/LooneY2K/common-pkg-svc/errors"
+)
 
 type Field struct {
 	Key   string
@@ -22,3 +26,9 @@ func Duration(key string, value time.Duration) Field {
 func Err(err error) Field {
 	return Field{Key: "error", Value: err}
 }
+
+// Stack returns the stack trace captured by the first AppError in err's chain.
+// The field is empty when err carries no trace.
+func Stack(err error) Field {
+	return Field{Key: "stacktrace", Value: apperr.StackTraceOf(err).String()}
+}
@@ -1,8 +1,10 @@
 package elog
 
 import (
+	"bytes"
 	"fmt"
 	"time"
+	"unicode/utf8"
 
 	"github.com/LooneY2K/common-pkg-svc/log/internal/bufferpool"
 )
@@ -16,16 +18,16 @@ func (l *Logger) logJSON(level Level, msg string, fields ...Field) {
 	buf.WriteString(`","level":"`)
 	buf.WriteString(level.String())
 	buf.WriteString(`","component":"`)
-	buf.WriteString(l.component)
+	appendEscaped(buf, l.component)
 	buf.WriteString(`","msg":"`)
-	buf.WriteString(msg)
+	appendEscaped(buf, msg)
 	buf.WriteString(`"`)
 
 	for _, f := range fields {
 		buf.WriteString(`,"`)
-		buf.WriteString(f.Key)
+		appendEscaped(buf, f.Key)
 		buf.WriteString(`":"`)
-		buf.WriteString(fmt.Sprint(f.Value))
+		appendEscaped(buf, fmt.Sprint(f.Value))
 		buf.WriteString(`"`)
 	}
 
@@ -33,3 +35,43 @@ func (l *Logger) logJSON(level Level, msg string, fields ...Field) {
 
 	l.out.Write(buf.Bytes())
 }
+
+const hexDigits = "0123456789abcdef"
+
+// appendEscaped writes s as the contents of a JSON string, escaping quotes,
+// backslashes and control characters such as the newlines in stack traces.
+func appendEscaped(buf *bytes.Buffer, s string) {
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				buf.WriteString(`\ufffd`)
+			} else {
+				buf.WriteString(s[i : i+size])
+			}
+			i += size
+			continue
+		}
+		switch c {
+		case '"', '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if c < 0x20 {
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hexDigits[c>>4])
+				buf.WriteByte(hexDigits[c&0xf])
+			} else {
+				buf.WriteByte(c)
+			}
+		}
+		i++
+	}
+}
@@ -3,6 +3,7 @@ package zap
 import (
 	"os"
 
+	apperr "github.com/LooneY2K/common-pkg-svc/errors"
 	"go.elastic.co/ecszap"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -40,3 +41,9 @@ func GetFileLogger(filepath string) *zap.SugaredLogger {
 	logger := zap.New(core, zap.AddCaller())
 	return logger.Sugar()
 }
+
+// StackTrace returns the ECS error.stack_trace field for the stack captured by
+// the first AppError in err's chain.
+func StackTrace(err error) zap.Field {
+	return zap.String("error.stack_trace", apperr.StackTraceOf(err).String())
+}
@@ -0,0 +1,236 @@
+package request
+
+import (
+	"encoding"
+	stderrors "errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LooneY2K/common-pkg-svc/errors"
+)
+
+// DecodeForm binds a URL-encoded or multipart form body of r into dst,
+// which must be a pointer to a struct. Fields are matched by their form
+// tag, falling back to the json tag and then the field name, so one struct
+// can serve JSON and form requests. Multipart files bind to
+// *multipart.FileHeader and []*multipart.FileHeader fields.
+func DecodeForm(r *http.Request, dst any, opts Options) *errors.AppError {
+	if r.Body != nil {
+		if n := opts.maxBytes(); n > 0 {
+			r.Body = http.MaxBytesReader(nil, r.Body, n)
+		}
+	}
+
+	var err error
+	var files map[string][]*multipart.FileHeader
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		maxMemory := opts.MaxMemory
+		if maxMemory <= 0 {
+			maxMemory = DefaultMaxMemory
+		}
+		err = r.ParseMultipartForm(maxMemory)
+		if err == nil {
+			files = r.MultipartForm.File
+		}
+	} else {
+		err = r.ParseForm()
+	}
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if stderrors.As(err, &maxErr) {
+			return jsonError(maxErr)
+		}
+		appErr := errors.BadRequest("request body is not a valid form")
+		appErr.Cause = err
+		return appErr
+	}
+	return bind(dst, r.PostForm, files)
+}
+
+// DecodeQuery binds the query string of r into dst, which must be a
+// pointer to a struct, using the same tags as DecodeForm.
+func DecodeQuery(r *http.Request, dst any) *errors.AppError {
+	return bind(dst, r.URL.Query(), nil)
+}
+
+var (
+	fileHeaderType  = reflect.TypeOf((*multipart.FileHeader)(nil))
+	timeType        = reflect.TypeOf(time.Time{})
+	durationType    = reflect.TypeOf(time.Duration(0))
+	textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+func bind(dst any, values url.Values, files map[string][]*multipart.FileHeader) *errors.AppError {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.InternalServerError(fmt.Sprintf("request: cannot bind into %T, want a pointer to a struct", dst))
+	}
+	var fieldErrs []error
+	bindStruct(rv.Elem(), "", values, files, &fieldErrs)
+	if len(fieldErrs) > 0 {
+		return fieldError(fieldErrs...)
+	}
+	return nil
+}
+
+func bindStruct(v reflect.Value, prefix string, values url.Values, files map[string][]*multipart.FileHeader, fieldErrs *[]error) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		name, ok := fieldName(sf)
+		if !ok {
+			continue
+		}
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct && !isScalar(sf.Type) {
+			bindStruct(fv, prefix, values, files, fieldErrs)
+			continue
+		}
+		key := prefix + name
+
+		switch {
+		case sf.Type == fileHeaderType:
+			if fhs := files[key]; len(fhs) > 0 {
+				fv.Set(reflect.ValueOf(fhs[0]))
+			}
+		case sf.Type == reflect.SliceOf(fileHeaderType):
+			if fhs := files[key]; len(fhs) > 0 {
+				fv.Set(reflect.ValueOf(fhs))
+			}
+		case sf.Type.Kind() == reflect.Struct && !isScalar(sf.Type):
+			bindStruct(fv, key+".", values, files, fieldErrs)
+		case sf.Type.Kind() == reflect.Slice && !isScalar(sf.Type):
+			raw, ok := values[key]
+			if !ok {
+				continue
+			}
+			slice := reflect.MakeSlice(sf.Type, len(raw), len(raw))
+			for j, s := range raw {
+				if err := setValue(slice.Index(j), s); err != nil {
+					*fieldErrs = append(*fieldErrs, errors.FieldErrorf(key, "%s", err))
+					break
+				}
+			}
+			fv.Set(slice)
+		default:
+			raw, ok := values[key]
+			if !ok || len(raw) == 0 {
+				continue
+			}
+			if err := setValue(fv, raw[0]); err != nil {
+				*fieldErrs = append(*fieldErrs, errors.FieldErrorf(key, "%s", err))
+			}
+		}
+	}
+}
+
+// fieldName returns the form key of sf: its form tag, else its json tag,
+// else the field name. ok is false for fields tagged "-".
+func fieldName(sf reflect.StructField) (name string, ok bool) {
+	for _, tag := range []string{"form", "json"} {
+		if v, found := sf.Tag.Lookup(tag); found {
+			name, _, _ = strings.Cut(v, ",")
+			if name == "-" {
+				return "", false
+			}
+			if name != "" {
+				return name, true
+			}
+		}
+	}
+	return sf.Name, true
+}
+
+// isScalar reports whether values of t are parsed from a single string.
+func isScalar(t reflect.Type) bool {
+	if t == timeType || t.Implements(textUnmarshaler) || reflect.PointerTo(t).Implements(textUnmarshaler) {
+		return true
+	}
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+// setValue parses s into v, describing the expected type on failure.
+func setValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Pointer {
+		ptr := reflect.New(v.Type().Elem())
+		if err := setValue(ptr.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(ptr)
+		return nil
+	}
+
+	switch {
+	case v.Type() == timeType:
+		for _, layout := range []string{time.RFC3339Nano, time.DateOnly} {
+			if tm, err := time.Parse(layout, s); err == nil {
+				v.Set(reflect.ValueOf(tm))
+				return nil
+			}
+		}
+		return stderrors.New("must be an RFC 3339 timestamp or a yyyy-mm-dd date")
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return stderrors.New("must be a duration such as 1m30s")
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	if v.CanAddr() {
+		if tu, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			if err := tu.UnmarshalText([]byte(s)); err != nil {
+				return fmt.Errorf("is invalid: %v", err)
+			}
+			return nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return stderrors.New("must be a boolean")
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return stderrors.New("must be an integer")
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return stderrors.New("must be a non-negative integer")
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return stderrors.New("must be a number")
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes([]byte(s))
+			return nil
+		}
+		fallthrough
+	default:
+		return fmt.Errorf("has unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+// Package request decodes inbound request bodies and query strings into
+// structs, reporting malformed input as BadRequest AppErrors.
+package request
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/LooneY2K/common-pkg-svc/errors"
+)
+
+// DefaultMaxBytes is the body size limit used when Options.MaxBytes is 0.
+const DefaultMaxBytes int64 = 1 << 20
+
+// DefaultMaxMemory is the multipart memory limit used when
+// Options.MaxMemory is 0; larger files are stored on disk.
+const DefaultMaxMemory int64 = 32 << 20
+
+// Options configures body decoding. The zero value applies the defaults.
+type Options struct {
+	// MaxBytes caps the request body size (default 1 MiB); a negative
+	// value disables the limit.
+	MaxBytes int64
+	// DisallowUnknownFields rejects JSON objects with fields dst lacks.
+	DisallowUnknownFields bool
+	// MaxMemory is the part of a multipart body held in memory (default 32 MiB).
+	MaxMemory int64
+}
+
+func (o Options) maxBytes() int64 {
+	if o.MaxBytes == 0 {
+		return DefaultMaxBytes
+	}
+	return o.MaxBytes
+}
+
+// DecodeJSON decodes the JSON body of r into dst, which must be a pointer.
+// The body must hold exactly one JSON value within the size limit. Syntax
+// errors report their byte offset and type mismatches or unknown fields
+// report the offending field, as a BadRequest whose cause lists a
+// FieldError where a field is known.
+func DecodeJSON(r *http.Request, dst any, opts Options) *errors.AppError {
+	if r.Body == nil || r.Body == http.NoBody {
+		return errors.BadRequest("request body must not be empty")
+	}
+	body := io.Reader(r.Body)
+	if n := opts.maxBytes(); n > 0 {
+		body = http.MaxBytesReader(nil, r.Body, n)
+	}
+
+	dec := json.NewDecoder(body)
+	if opts.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(dst); err != nil {
+		return jsonError(err)
+	}
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		var maxErr *http.MaxBytesError
+		if stderrors.As(err, &maxErr) {
+			return jsonError(err)
+		}
+		appErr := errors.BadRequest("request body must contain a single JSON value")
+		appErr.Cause = err
+		return appErr
+	}
+	return nil
+}
+
+func jsonError(err error) *errors.AppError {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+		maxErr    *http.MaxBytesError
+		appErr    *errors.AppError
+	)
+	switch {
+	case stderrors.As(err, &syntaxErr):
+		appErr = errors.BadRequest(fmt.Sprintf("request body contains malformed JSON at offset %d", syntaxErr.Offset))
+	case stderrors.Is(err, io.ErrUnexpectedEOF):
+		appErr = errors.BadRequest("request body contains malformed JSON")
+	case stderrors.Is(err, io.EOF):
+		appErr = errors.BadRequest("request body must not be empty")
+	case stderrors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			appErr = errors.BadRequest(fmt.Sprintf("request body must be %s, got %s", typeName(typeErr), typeErr.Value))
+			break
+		}
+		return fieldError(errors.FieldErrorf(field, "must be %s, got %s (at offset %d)", typeName(typeErr), typeErr.Value, typeErr.Offset))
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return fieldError(errors.FieldErrorf(field, "is not a known field"))
+	case stderrors.As(err, &maxErr):
+		appErr = errors.BadRequest(fmt.Sprintf("request body must not exceed %d bytes", maxErr.Limit))
+	default:
+		appErr = errors.BadRequest("request body could not be decoded")
+	}
+	appErr.Cause = err
+	return appErr
+}
+
+// typeName describes the JSON value a Go type expects, with its article.
+func typeName(e *json.UnmarshalTypeError) string {
+	switch e.Type.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Struct, reflect.Map:
+		return "a JSON object"
+	default:
+		return "a " + e.Type.String()
+	}
+}
+
+// fieldError wraps field errors in a BadRequest listing each of them.
+func fieldError(fieldErrs ...error) *errors.AppError {
+	cause := &errors.ErrorList{Errors: fieldErrs}
+	appErr := errors.BadRequest(errors.PublicError(cause))
+	appErr.Cause = cause
+	return appErr
+}
@@ -0,0 +1,356 @@
+package respond
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ugorji/go/codec"
+)
+
+// Codec encodes response bodies for one media type.
+type Codec interface {
+	ContentType() string
+	Encode(w io.Writer, v any) error
+}
+
+// Built-in codecs, registered by default in this order. JSON is the
+// default used when the request has no Accept header or accepts */*.
+var (
+	JSONCodec    Codec = jsonCodec{}
+	XMLCodec     Codec = xmlCodec{}
+	MsgpackCodec Codec = msgpackCodec{}
+	CBORCodec    Codec = cborCodec{}
+	TextCodec    Codec = textCodec{}
+)
+
+type registeredCodec struct {
+	mediaType string
+	codec     Codec
+}
+
+var (
+	codecMu sync.RWMutex
+	codecs  []registeredCodec
+)
+
+func init() {
+	RegisterCodec(JSONCodec)
+	RegisterCodec(XMLCodec, "text/xml")
+	RegisterCodec(MsgpackCodec, "application/x-msgpack")
+	RegisterCodec(CBORCodec)
+	RegisterCodec(TextCodec)
+}
+
+// RegisterCodec makes c available for content negotiation under its
+// ContentType and any aliases. Registering a media type again replaces
+// the previous codec for it.
+func RegisterCodec(c Codec, aliases ...string) {
+	codecMu.Lock()
+	defer codecMu.Unlock()
+	for _, ct := range append([]string{c.ContentType()}, aliases...) {
+		mt, _, err := mime.ParseMediaType(ct)
+		if err != nil {
+			continue
+		}
+		replaced := false
+		for i := range codecs {
+			if codecs[i].mediaType == mt {
+				codecs[i].codec = c
+				replaced = true
+			}
+		}
+		if !replaced {
+			codecs = append(codecs, registeredCodec{mediaType: mt, codec: c})
+		}
+	}
+}
+
+// NegotiateCodec picks the registered codec that best matches the request's
+// Accept header, honouring q-values and wildcards. A missing Accept header
+// selects JSON. Returns false when nothing acceptable is registered.
+func NegotiateCodec(r *http.Request) (Codec, bool) {
+	accept := ""
+	if r != nil {
+		accept = r.Header.Get("Accept")
+	}
+	if strings.TrimSpace(accept) == "" {
+		return JSONCodec, true
+	}
+
+	codecMu.RLock()
+	defer codecMu.RUnlock()
+	ranges := parseAccept(accept)
+	for _, ar := range ranges {
+		if ar.q <= 0 {
+			continue
+		}
+		for _, rc := range codecs {
+			if ar.matches(rc.mediaType) && !refused(ranges, rc.mediaType) {
+				return rc.codec, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// refused reports whether the most specific range matching mediaType has
+// q=0, so "application/json;q=0, */*" excludes JSON but "text/*;q=0,
+// text/csv" still allows CSV.
+func refused(ranges []acceptRange, mediaType string) bool {
+	best := -1
+	isRefused := false
+	for _, ar := range ranges {
+		if s := specificity(ar.mediaType); ar.matches(mediaType) && s > best {
+			best, isRefused = s, ar.q <= 0
+		}
+	}
+	return isRefused
+}
+
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+func (a acceptRange) matches(mediaType string) bool {
+	if a.mediaType == "*/*" || a.mediaType == mediaType {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(a.mediaType, "/*")
+	return ok && strings.HasPrefix(mediaType, prefix+"/")
+}
+
+// parseAccept returns the ranges ordered by preference: q-value first,
+// then specificity, then header order. Ranges with q=0 come last and mark
+// refused types.
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if qs, ok := params["q"]; ok {
+			if v, err := strconv.ParseFloat(qs, 64); err == nil {
+				q = v
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType: mt, q: q})
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].q != ranges[j].q {
+			return ranges[i].q > ranges[j].q
+		}
+		return specificity(ranges[i].mediaType) > specificity(ranges[j].mediaType)
+	})
+	return ranges
+}
+
+func specificity(mediaType string) int {
+	switch {
+	case mediaType == "*/*":
+		return 0
+	case strings.HasSuffix(mediaType, "/*"):
+		return 1
+	default:
+		return 2
+	}
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string { return "application/json" }
+
+func (jsonCodec) Encode(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+type xmlCodec struct{}
+
+func (xmlCodec) ContentType() string { return "application/xml" }
+
+// Encode writes v under a <response> root. Values are first normalized
+// through their JSON form so element names follow the json tags.
+func (xmlCodec) Encode(w io.Writer, v any) error {
+	tree, err := normalize(v)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	if err := encodeXML(enc, "response", tree); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) ContentType() string { return "application/msgpack" }
+
+func (msgpackCodec) Encode(w io.Writer, v any) error {
+	tree, err := normalize(v)
+	if err != nil {
+		return err
+	}
+	h := &codec.MsgpackHandle{}
+	h.WriteExt = true
+	return codec.NewEncoder(w, h).Encode(tree)
+}
+
+type cborCodec struct{}
+
+func (cborCodec) ContentType() string { return "application/cbor" }
+
+func (cborCodec) Encode(w io.Writer, v any) error {
+	tree, err := normalize(v)
+	if err != nil {
+		return err
+	}
+	return codec.NewEncoder(w, &codec.CborHandle{}).Encode(tree)
+}
+
+type textCodec struct{}
+
+func (textCodec) ContentType() string { return "text/plain; charset=utf-8" }
+
+// Encode writes strings, errors and fmt.Stringers as is. For the Response
+// envelope it writes the message of errors and the data otherwise; any
+// other value is written as indented JSON.
+func (c textCodec) Encode(w io.Writer, v any) error {
+	switch val := v.(type) {
+	case Response:
+		if val.Error || val.Data == nil {
+			_, err := fmt.Fprintf(w, "%d %s\n", val.Status, val.Message)
+			return err
+		}
+		return c.Encode(w, val.Data)
+	case string:
+		_, err := io.WriteString(w, val+"\n")
+		return err
+	case []byte:
+		_, err := w.Write(val)
+		return err
+	case error:
+		_, err := io.WriteString(w, val.Error()+"\n")
+		return err
+	case fmt.Stringer:
+		_, err := io.WriteString(w, val.String()+"\n")
+		return err
+	default:
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(b, '\n'))
+		return err
+	}
+}
+
+// normalize converts v into a tree of map[string]any, []any and scalars via
+// its JSON encoding, so every format shares the json field names and
+// custom marshalers. Integral numbers become int64, others float64.
+func normalize(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var tree any
+	if err := dec.Decode(&tree); err != nil {
+		return nil, err
+	}
+	return convertNumbers(tree), nil
+}
+
+func convertNumbers(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, item := range val {
+			val[k] = convertNumbers(item)
+		}
+		return val
+	case []any:
+		for i, item := range val {
+			val[i] = convertNumbers(item)
+		}
+		return val
+	case json.Number:
+		if n, err := val.Int64(); err == nil {
+			return n
+		}
+		f, _ := val.Float64()
+		return f
+	default:
+		return v
+	}
+}
+
+func encodeXML(enc *xml.Encoder, name string, v any) error {
+	start := xml.StartElement{Name: xml.Name{Local: xmlName(name)}}
+	switch val := v.(type) {
+	case nil:
+		return enc.EncodeElement("", start)
+	case map[string]any:
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := encodeXML(enc, k, val[k]); err != nil {
+				return err
+			}
+		}
+		return enc.EncodeToken(start.End())
+	case []any:
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		for _, item := range val {
+			if err := encodeXML(enc, "item", item); err != nil {
+				return err
+			}
+		}
+		return enc.EncodeToken(start.End())
+	default:
+		return enc.EncodeElement(val, start)
+	}
+}
+
+// xmlName turns a JSON key into a valid XML element name.
+func xmlName(s string) string {
+	if s == "" {
+		return "_"
+	}
+	var b strings.Builder
+	for i, r := range s {
+		valid := r == '_' || r == '-' || r == '.' ||
+			(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+		if i == 0 && (r == '-' || r == '.' || (r >= '0' && r <= '9')) {
+			b.WriteByte('_')
+		}
+		if valid {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,127 @@
+// Package gin renders the respond envelope from gin handlers, so gin and
+// chi services return identical bodies and status codes.
+package gin
+
+import (
+	"net/http"
+
+	"github.com/LooneY2K/common-pkg-svc/errors"
+	"github.com/LooneY2K/common-pkg-svc/respond"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func OK(c *gin.Context, data interface{}) *errors.AppError {
+	return respond.OK(c.Writer, data)
+}
+
+func Created(c *gin.Context, data interface{}) *errors.AppError {
+	return respond.Created(c.Writer, data)
+}
+
+func Fail(c *gin.Context, data interface{}) *errors.AppError {
+	return respond.Fail(c.Writer, data)
+}
+
+// Page writes items with pagination metadata and Link headers.
+func Page(c *gin.Context, items any, info respond.PageInfo) *errors.AppError {
+	return respond.Page(c.Writer, items, info)
+}
+
+// Error records err on the context and renders it as respond.ErrorFor
+// does: the envelope by default, problem+json when negotiated or enabled
+// with UseErrorFormat. A nil err writes nothing.
+func Error(c *gin.Context, err error) *errors.AppError {
+	if err == nil {
+		return nil
+	}
+	_ = c.Error(err)
+	return respond.ErrorFor(c.Writer, c.Request, err)
+}
+
+// Errors renders one or more errors in a single response, listing every
+// error under "errors" with the most severe status.
+func Errors(c *gin.Context, errs ...error) *errors.AppError {
+	err := errors.MultiError(errs...)
+	if err == nil {
+		return nil
+	}
+	return Error(c, err)
+}
+
+// AbortWithError renders err and stops the remaining handlers in the chain.
+func AbortWithError(c *gin.Context, err error) *errors.AppError {
+	c.Abort()
+	return Error(c, err)
+}
+
+// Handle mounts a respond.Handler, such as one built with respond.Endpoint,
+// on gin. A returned error is rendered like Error, or only recorded with
+// c.Error when the handler already wrote a response.
+func Handle(h respond.Handler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := h(c.Writer, c.Request)
+		if err == nil {
+			return
+		}
+		if c.Writer.Written() {
+			_ = c.Error(err)
+			return
+		}
+		Error(c, err)
+	}
+}
+
+// HandleErrors returns middleware that gives every request a request ID,
+// recovers panics into an InternalServerError carrying the panic stack, and
+// renders the errors attached with c.Error when the handlers wrote no
+// response. Every attached error is logged to lgr at warn (4xx) or error
+// (5xx); lgr may be nil. Use it in place of gin.Recovery.
+func HandleErrors(lgr *zap.SugaredLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = respond.EnsureRequestID(c.Writer, c.Request)
+		defer func() {
+			if v := recover(); v != nil {
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+				c.Abort()
+				err := respond.PanicError(v)
+				if c.Writer.Written() {
+					respond.LogError(lgr, c.Request, err)
+					return
+				}
+				respond.HandleError(c.Writer, c.Request, lgr, err)
+			}
+		}()
+
+		c.Next()
+		if len(c.Errors) == 0 {
+			return
+		}
+		errs := make([]error, len(c.Errors))
+		for i, ginErr := range c.Errors {
+			errs[i] = ginErr.Err
+		}
+		err := errors.MultiError(errs...)
+		if c.Writer.Written() {
+			respond.LogError(lgr, c.Request, err)
+			return
+		}
+		respond.HandleError(c.Writer, c.Request, lgr, err)
+	}
+}
+
+// ErrorHandler is HandleErrors without logging.
+func ErrorHandler() gin.HandlerFunc {
+	return HandleErrors(nil)
+}
+
+// UseErrorFormat returns middleware that sets the default error format for
+// every request routed through it, like respond.UseErrorFormat for chi.
+func UseErrorFormat(format respond.ErrorFormat) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(respond.WithErrorFormat(c.Request.Context(), format))
+		c.Next()
+	}
+}
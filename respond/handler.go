@@ -0,0 +1,90 @@
+package respond
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+
+	"github.com/LooneY2K/common-pkg-svc/request"
+	validate "github.com/LooneY2K/common-pkg-svc/validator"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Handler is an http.Handler that returns its error instead of writing it.
+// The error is attached for HandleErrors when that middleware is installed
+// and rendered with ErrorFor otherwise, unless the handler already wrote a
+// response; install HandleErrors to have such errors logged. Mount it on
+// chi with router.Method or router.Handle, and on gin with the respond/gin
+// Handle adapter.
+type Handler func(http.ResponseWriter, *http.Request) error
+
+// ServeHTTP implements http.Handler.
+func (h Handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if _, ok := r.Context().Value(attachedErrorsKey{}).(*attachedErrors); ok {
+		AttachError(r, h(rw, r))
+		return
+	}
+	ww := middleware.NewWrapResponseWriter(rw, r.ProtoMajor)
+	if err := h(ww, r); err != nil && ww.Status() == 0 && ww.BytesWritten() == 0 {
+		ErrorFor(ww, r, err)
+	}
+}
+
+// StatusCoder lets an Endpoint response choose its success status.
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// Endpoint adapts a typed function into a Handler. The request body, when
+// present, is decoded into Req with request.DecodeJSON and struct requests are checked
+// with validate.ValidateRequestDto before fn is called. The result is
+// rendered in the Response envelope with the codec negotiated for the
+// request: 200 by default, or the StatusCode of a Resp implementing
+// StatusCoder.
+func Endpoint[Req, Resp any](fn func(context.Context, Req) (Resp, error)) Handler {
+	return func(rw http.ResponseWriter, r *http.Request) error {
+		var req Req
+		if err := decodeBody(r, &req); err != nil {
+			return err
+		}
+		if isStruct(req) {
+			if appErr := validate.ValidateRequestDto(req); appErr != nil {
+				return appErr
+			}
+		}
+
+		resp, err := fn(r.Context(), req)
+		if err != nil {
+			return err
+		}
+		status := http.StatusOK
+		if sc, ok := any(resp).(StatusCoder); ok {
+			status = sc.StatusCode()
+		}
+		NewWriter(rw).Negotiate(r).Respond(Response{Status: status, Data: resp})
+		return nil
+	}
+}
+
+func decodeBody(r *http.Request, dst any) error {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	if appErr := request.DecodeJSON(r, dst, request.Options{}); appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// isStruct reports whether v is a struct or a non-nil pointer to one,
+// the only values the validator accepts.
+func isStruct(v any) bool {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return false
+		}
+		rv = rv.Elem()
+	}
+	return rv.Kind() == reflect.Struct
+}
@@ -0,0 +1,142 @@
+package respond
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/LooneY2K/common-pkg-svc/errors"
+	zaplog "github.com/LooneY2K/common-pkg-svc/log/logger"
+	validate "github.com/LooneY2K/common-pkg-svc/validator"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// HandleErrors returns chi middleware that gives every request a request
+// ID, recovers panics into an InternalServerError carrying the panic stack,
+// and renders errors attached with AttachError when the handler wrote no
+// response, or only logs them when it did. Errors are logged to lgr at
+// warn (4xx) or error (5xx); lgr may be nil.
+func HandleErrors(lgr *zap.SugaredLogger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			r = EnsureRequestID(rw, r)
+			holder := &attachedErrors{}
+			r = r.WithContext(context.WithValue(r.Context(), attachedErrorsKey{}, holder))
+			ww := middleware.NewWrapResponseWriter(rw, r.ProtoMajor)
+			written := func() bool { return ww.Status() != 0 || ww.BytesWritten() > 0 }
+
+			defer func() {
+				if v := recover(); v != nil {
+					if v == http.ErrAbortHandler {
+						panic(v)
+					}
+					err := PanicError(v)
+					if written() {
+						LogError(lgr, r, err)
+						return
+					}
+					HandleError(ww, r, lgr, err)
+				}
+			}()
+
+			next.ServeHTTP(ww, r)
+			if err := holder.err(); err != nil {
+				if written() {
+					LogError(lgr, r, err)
+					return
+				}
+				HandleError(ww, r, lgr, err)
+			}
+		})
+	}
+}
+
+// AttachError records err on a request served through HandleErrors, to be
+// rendered once the handler returns without writing a response.
+// It does nothing when the middleware is not installed.
+func AttachError(r *http.Request, err error) {
+	if err == nil {
+		return
+	}
+	if holder, ok := r.Context().Value(attachedErrorsKey{}).(*attachedErrors); ok {
+		holder.add(err)
+	}
+}
+
+// PanicError converts a recovered panic value into an InternalServerError
+// with a generic user message and the stack of the panicking goroutine.
+func PanicError(v any) *errors.AppError {
+	appErr := errors.InternalServerError(fmt.Sprintf("panic: %v", v))
+	appErr.UserMsg = http.StatusText(http.StatusInternalServerError)
+	if err, ok := v.(error); ok {
+		appErr.Cause = err
+	}
+	return appErr
+}
+
+// HandleError renders err for r with ErrorFor and logs it with LogError.
+func HandleError(rw http.ResponseWriter, r *http.Request, lgr *zap.SugaredLogger, err error) *errors.AppError {
+	if err == nil {
+		return nil
+	}
+	LogError(lgr, r, err)
+	return ErrorFor(rw, r, err)
+}
+
+// LogError logs err for r at warn for client errors (4xx) and at error,
+// with the stack trace, for server errors (5xx). lgr may be nil.
+func LogError(lgr *zap.SugaredLogger, r *http.Request, err error) {
+	if lgr == nil || err == nil {
+		return
+	}
+	err = normalizeError(err)
+	status := errorResponse(err).Status
+	kv := []any{
+		"request_id", RequestID(r),
+		"method", r.Method,
+		"path", r.URL.Path,
+		"status", status,
+		"error", err.Error(),
+	}
+	if status >= http.StatusInternalServerError {
+		if errors.StackTraceOf(err) != nil {
+			kv = append(kv, zaplog.StackTrace(err))
+		}
+		lgr.Errorw("request failed", kv...)
+		return
+	}
+	lgr.Warnw("request failed", kv...)
+}
+
+// normalizeError turns go-playground/validator errors, such as those
+// returned by gin binding, into a 400 listing each invalid field.
+func normalizeError(err error) error {
+	var verrs validator.ValidationErrors
+	if stderrors.As(err, &verrs) {
+		return validate.FormatValidationErrors(verrs)
+	}
+	return err
+}
+
+type attachedErrorsKey struct{}
+
+type attachedErrors struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (a *attachedErrors) add(err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.errs = append(a.errs, err)
+}
+
+func (a *attachedErrors) err() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return errors.MultiError(a.errs...)
+}
@@ -0,0 +1,205 @@
+package respond
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/LooneY2K/common-pkg-svc/errors"
+)
+
+// PageOptions configures ParsePage.
+type PageOptions struct {
+	// DefaultLimit is used when the request has no limit (default 20).
+	DefaultLimit int
+	// MaxLimit caps the limit a client may request (default 100).
+	MaxLimit int
+	// MaxOffset caps the offset a client may request; 0 means no cap.
+	MaxOffset int
+}
+
+// PageRequest holds the validated pagination parameters of a list request.
+// Exactly one of Offset and Cursor is meaningful: Cursor when it is set.
+type PageRequest struct {
+	Limit  int
+	Offset int
+	Cursor string
+	URL    *url.URL
+}
+
+// PageInfo is the "pagination" member of a paginated response. Build it
+// with OffsetPage or CursorPage so the right members are present.
+type PageInfo struct {
+	Total      *int64 `json:"total,omitempty"`
+	Limit      int    `json:"limit"`
+	Offset     *int   `json:"offset,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+
+	// URL is the request URL that Link headers are built from.
+	URL *url.URL `json:"-"`
+}
+
+// ParsePage reads and validates the limit, offset and cursor query
+// parameters of r. Invalid values yield a BadRequest whose cause lists a
+// FieldError per offending parameter.
+func ParsePage(r *http.Request, opts PageOptions) (PageRequest, *errors.AppError) {
+	if opts.DefaultLimit <= 0 {
+		opts.DefaultLimit = 20
+	}
+	if opts.MaxLimit <= 0 {
+		opts.MaxLimit = 100
+	}
+	if opts.DefaultLimit > opts.MaxLimit {
+		opts.DefaultLimit = opts.MaxLimit
+	}
+
+	q := r.URL.Query()
+	page := PageRequest{Limit: opts.DefaultLimit, Cursor: q.Get("cursor"), URL: r.URL}
+	var fieldErrs []error
+
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > opts.MaxLimit {
+			fieldErrs = append(fieldErrs, errors.FieldErrorf("limit", "must be an integer between 1 and %d", opts.MaxLimit))
+		} else {
+			page.Limit = n
+		}
+	}
+	if s := q.Get("offset"); s != "" {
+		n, err := strconv.Atoi(s)
+		switch {
+		case err != nil || n < 0:
+			fieldErrs = append(fieldErrs, errors.FieldErrorf("offset", "must be a non-negative integer"))
+		case opts.MaxOffset > 0 && n > opts.MaxOffset:
+			fieldErrs = append(fieldErrs, errors.FieldErrorf("offset", "must not exceed %d", opts.MaxOffset))
+		case page.Cursor != "":
+			fieldErrs = append(fieldErrs, errors.FieldErrorf("offset", "cannot be combined with cursor"))
+		default:
+			page.Offset = n
+		}
+	}
+
+	if len(fieldErrs) > 0 {
+		cause := errors.MultiError(fieldErrs...)
+		appErr := errors.BadRequest(errors.PublicError(cause))
+		appErr.Cause = cause
+		return PageRequest{}, appErr
+	}
+	return page, nil
+}
+
+// OffsetPage describes a page of an offset-paginated list with total items.
+func OffsetPage(req PageRequest, total int64) PageInfo {
+	offset := req.Offset
+	return PageInfo{
+		Total:   &total,
+		Limit:   req.Limit,
+		Offset:  &offset,
+		HasMore: int64(req.Offset+req.Limit) < total,
+		URL:     req.URL,
+	}
+}
+
+// CursorPage describes a page of a cursor-paginated list. An empty next
+// cursor marks the last page.
+func CursorPage(req PageRequest, next, prev string) PageInfo {
+	return PageInfo{
+		Limit:      req.Limit,
+		NextCursor: next,
+		PrevCursor: prev,
+		HasMore:    next != "",
+		URL:        req.URL,
+	}
+}
+
+// EncodeCursor encodes v as an opaque URL-safe cursor.
+func EncodeCursor(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// DecodeCursor decodes a cursor produced by EncodeCursor into v.
+// Malformed cursors yield a BadRequest for the cursor field.
+func DecodeCursor(cursor string, v any) *errors.AppError {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil {
+		err = json.Unmarshal(b, v)
+	}
+	if err != nil {
+		fe := errors.FieldErrorf("cursor", "is invalid")
+		appErr := errors.BadRequest(fe.Error())
+		appErr.Cause = fe
+		return appErr
+	}
+	return nil
+}
+
+// Page writes items with pagination metadata in a 200 envelope and sets
+// RFC 8288 Link headers (first, prev, next, last) derived from info.URL.
+func Page(rw http.ResponseWriter, items any, info PageInfo) *errors.AppError {
+	w := NewWriter(rw)
+	if link := info.linkHeader(); link != "" {
+		w.Header("Link", link)
+	}
+	return w.Respond(Response{
+		Status:     http.StatusOK,
+		Data:       items,
+		Pagination: &info,
+	})
+}
+
+func (p PageInfo) linkHeader() string {
+	if p.URL == nil {
+		return ""
+	}
+	var links []string
+	add := func(rel string, set func(url.Values)) {
+		u := *p.URL
+		q := u.Query()
+		q.Set("limit", strconv.Itoa(p.Limit))
+		q.Del("offset")
+		q.Del("cursor")
+		set(q)
+		u.RawQuery = q.Encode()
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, u.String(), rel))
+	}
+
+	if p.Offset == nil {
+		if p.PrevCursor != "" {
+			add("prev", func(q url.Values) { q.Set("cursor", p.PrevCursor) })
+		}
+		if p.NextCursor != "" {
+			add("next", func(q url.Values) { q.Set("cursor", p.NextCursor) })
+		}
+		return strings.Join(links, ", ")
+	}
+
+	offset := *p.Offset
+	setOffset := func(n int) func(url.Values) {
+		return func(q url.Values) { q.Set("offset", strconv.Itoa(n)) }
+	}
+	add("first", setOffset(0))
+	if offset > 0 {
+		add("prev", setOffset(max(offset-p.Limit, 0)))
+	}
+	if p.HasMore {
+		add("next", setOffset(offset+p.Limit))
+	}
+	if p.Total != nil && p.Limit > 0 {
+		last := 0
+		if *p.Total > 0 {
+			last = int((*p.Total-1)/int64(p.Limit)) * p.Limit
+		}
+		add("last", setOffset(last))
+	}
+	return strings.Join(links, ", ")
+}
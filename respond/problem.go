@@ -0,0 +1,234 @@
+package respond
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/LooneY2K/common-pkg-svc/errors"
+)
+
+// ProblemContentType is the media type of RFC 9457 problem details.
+const ProblemContentType = "application/problem+json"
+
+// ErrorFormat selects how ErrorFor renders errors.
+type ErrorFormat uint8
+
+const (
+	// FormatEnvelope renders errors in the Response envelope.
+	FormatEnvelope ErrorFormat = iota
+	// FormatProblem renders errors as RFC 9457 application/problem+json.
+	FormatProblem
+)
+
+// Problem is an RFC 9457 problem details object. Extensions are marshaled
+// as top-level members alongside the standard ones.
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]any
+}
+
+// ProblemFieldError locates a single invalid field in the request body.
+type ProblemFieldError struct {
+	Detail  string `json:"detail"`
+	Pointer string `json:"pointer"`
+}
+
+var (
+	problemMu       sync.RWMutex
+	problemTypeBase string
+)
+
+// SetProblemTypeBase sets the URI prefix for problem "type" members. The
+// error code is appended in kebab case, e.g. base + "not-found". When unset,
+// type is "about:blank".
+func SetProblemTypeBase(uri string) {
+	problemMu.Lock()
+	defer problemMu.Unlock()
+	problemTypeBase = uri
+}
+
+// NewProblem builds problem details for err. AppErrors contribute their
+// status, user message, code, trace and request IDs and field errors, also
+// when a list of field errors is their cause; an ErrorList takes its most
+// severe status; any other error becomes a 500 with a generic detail. r may be nil; when set,
+// its path becomes the instance and its request ID is used when err has none.
+func NewProblem(err error, r *http.Request) Problem {
+	status := http.StatusInternalServerError
+	ext := make(map[string]any)
+	var appErr *errors.AppError
+	if list, ok := err.(*errors.ErrorList); ok {
+		status = list.HTTPStatus()
+	} else if errors.As(err, &appErr) {
+		status = appErr.HTTPStatus()
+		ext["code"] = appErr.Code
+		if appErr.TraceID != "" {
+			ext["trace_id"] = appErr.TraceID
+		}
+	}
+	if id := requestIDFor(err, r); id != "" {
+		ext["request_id"] = id
+	}
+	if fields := errors.FieldErrors(err); len(fields) > 0 {
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fieldErrs := make([]ProblemFieldError, len(names))
+		for i, name := range names {
+			fieldErrs[i] = ProblemFieldError{Detail: fields[name], Pointer: jsonPointer(name)}
+		}
+		ext["errors"] = fieldErrs
+	}
+
+	p := Problem{
+		Type:       problemType(appErr),
+		Title:      http.StatusText(status),
+		Status:     status,
+		Detail:     errors.PublicError(err),
+		Extensions: ext,
+	}
+	if r != nil && r.URL != nil {
+		p.Instance = r.URL.Path
+	}
+	return p
+}
+
+// MarshalJSON flattens Extensions into the problem object. Standard members
+// take precedence over extensions with the same name.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	m := make(map[string]any, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+	m["type"] = p.Type
+	m["title"] = p.Title
+	m["status"] = p.Status
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	}
+	return json.Marshal(m)
+}
+
+// ProblemJSON writes err as application/problem+json.
+func ProblemJSON(rw http.ResponseWriter, r *http.Request, err error) *errors.AppError {
+	p := NewProblem(normalizeError(err), r)
+	return NewWriter(rw).ContentType(ProblemContentType).JSON(p.Status, p)
+}
+
+// ErrorFor renders err in the format negotiated for r: problem+json when
+// the Accept header asks for it, otherwise the format set on the router
+// with UseErrorFormat, otherwise the Response envelope encoded with the
+// codec negotiated from the Accept header. The request ID of err, or else
+// of r, is included in either format, and validator errors become a 400
+// listing each invalid field.
+func ErrorFor(rw http.ResponseWriter, r *http.Request, err error) *errors.AppError {
+	if err == nil {
+		return nil
+	}
+	err = normalizeError(err)
+	if negotiateErrorFormat(r) == FormatProblem {
+		return ProblemJSON(rw, r, err)
+	}
+	response := errorResponse(err)
+	response.RequestID = requestIDFor(err, r)
+	return NewWriter(rw).Negotiate(r).Respond(response)
+}
+
+type errorFormatKey struct{}
+
+// UseErrorFormat returns middleware that sets the default error format for
+// every request routed through it. Mount it on a chi router with r.Use.
+func UseErrorFormat(format ErrorFormat) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(rw, r.WithContext(WithErrorFormat(r.Context(), format)))
+		})
+	}
+}
+
+// WithErrorFormat returns a copy of ctx carrying format as the default error format.
+func WithErrorFormat(ctx context.Context, format ErrorFormat) context.Context {
+	return context.WithValue(ctx, errorFormatKey{}, format)
+}
+
+func negotiateErrorFormat(r *http.Request) ErrorFormat {
+	if r == nil {
+		return FormatEnvelope
+	}
+	ranges := parseAccept(r.Header.Get("Accept"))
+	if refused(ranges, ProblemContentType) {
+		return FormatEnvelope
+	}
+	for _, ar := range ranges {
+		if ar.q > 0 && ar.mediaType == ProblemContentType {
+			return FormatProblem
+		}
+	}
+	if format, ok := r.Context().Value(errorFormatKey{}).(ErrorFormat); ok {
+		return format
+	}
+	return FormatEnvelope
+}
+
+// jsonPointer turns a field path such as "address.city" or "items[0].name"
+// into the RFC 6901 pointer "#/address/city" or "#/items/0/name". Map keys
+// in brackets become segments too.
+func jsonPointer(path string) string {
+	escape := strings.NewReplacer("~", "~0", "/", "~1")
+	var b strings.Builder
+	b.WriteString("#")
+	for _, seg := range strings.Split(path, ".") {
+		name, indices, _ := strings.Cut(seg, "[")
+		if name != "" {
+			b.WriteString("/" + escape.Replace(name))
+		}
+		for _, index := range strings.Split(indices, "[") {
+			if index = strings.TrimSuffix(index, "]"); index != "" {
+				b.WriteString("/" + escape.Replace(index))
+			}
+		}
+	}
+	return b.String()
+}
+
+func problemType(appErr *errors.AppError) string {
+	problemMu.RLock()
+	base := problemTypeBase
+	problemMu.RUnlock()
+	if base == "" || appErr == nil || appErr.Code == "" {
+		return "about:blank"
+	}
+	return base + strings.ToLower(strings.ReplaceAll(string(appErr.Code), "_", "-"))
+}
+
+// toAppError returns the AppError in err's chain, or wraps err as an
+// internal error with a generic user message.
+func toAppError(err error) *errors.AppError {
+	var appErr *errors.AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return errors.New(errors.CodeInternal, err.Error(), errors.PublicError(err), errors.WithCause(err))
+}
+
+// requestIDFor returns the request ID of the AppError in err's chain,
+// falling back to the ID carried by r.
+func requestIDFor(err error, r *http.Request) string {
+	var appErr *errors.AppError
+	if errors.As(err, &appErr) && appErr.RequestID != "" {
+		return appErr.RequestID
+	}
+	return RequestID(r)
+}
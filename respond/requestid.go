@@ -0,0 +1,62 @@
+package respond
+
+import (
+	"context"
+	"crypto/rand"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// RequestIDHeader carries the request ID on requests and responses.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLen bounds inbound request IDs echoed back to clients.
+const maxRequestIDLen = 128
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying id as the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the ID of r set by WithRequestID or chi's RequestID
+// middleware, or "" when there is none.
+func RequestID(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+	if id, ok := r.Context().Value(requestIDKey{}).(string); ok && id != "" {
+		return id
+	}
+	return middleware.GetReqID(r.Context())
+}
+
+// EnsureRequestID returns r carrying a request ID, reusing the one already
+// on the context or a well-formed X-Request-ID header and generating one
+// otherwise. The ID is echoed in the X-Request-ID response header.
+func EnsureRequestID(rw http.ResponseWriter, r *http.Request) *http.Request {
+	id := RequestID(r)
+	if id == "" {
+		id = r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = rand.Text()
+		}
+		r = r.WithContext(WithRequestID(r.Context(), id))
+	}
+	rw.Header().Set(RequestIDHeader, id)
+	return r
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
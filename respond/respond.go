@@ -1,32 +1,33 @@
 package respond
 
 import (
-	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/LooneY2K/common-pkg-svc/errors"
 )
 
 type Response struct {
-	Status  int         `json:"status"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   bool        `json:"error"`
-	Message string      `json:"message,omitempty"`
+	Status     int                `json:"status"`
+	Data       interface{}        `json:"data,omitempty"`
+	Error      bool               `json:"error"`
+	Message    string             `json:"message,omitempty"`
+	Errors     []*errors.AppError `json:"errors,omitempty"`
+	Pagination *PageInfo          `json:"pagination,omitempty"`
+	RequestID  string             `json:"request_id,omitempty"`
 }
 
 func toJSON(rw http.ResponseWriter, status int, data interface{}, message string, isError bool) *errors.AppError {
-	rw.WriteHeader(status)
-	rw.Header().Set("Content-Type", "application/json")
-	response := Response{
+	return writeJSON(rw, Response{
 		Status:  status,
 		Data:    data,
 		Error:   isError,
 		Message: message,
-	}
-	if err := json.NewEncoder(rw).Encode(response); err != nil {
-		return errors.InternalServerError(err.Error())
-	}
-	return nil
+	})
+}
+
+func writeJSON(rw http.ResponseWriter, response Response) *errors.AppError {
+	return NewWriter(rw).Respond(response)
 }
 
 func OK(rw http.ResponseWriter, data interface{}) *errors.AppError {
@@ -42,5 +43,42 @@ func Fail(rw http.ResponseWriter, data interface{}) *errors.AppError {
 }
 
 func Error(rw http.ResponseWriter, appErr *errors.AppError) *errors.AppError {
-	return toJSON(rw, appErr.StatusCode, nil, appErr.Error(), true)
+	return toJSON(rw, appErr.HTTPStatus(), nil, appErr.UserMsg, true)
+}
+
+// Errors renders one or more errors in a single response. The status is the
+// most severe among them and every error is listed under "errors".
+func Errors(rw http.ResponseWriter, errs ...error) *errors.AppError {
+	err := errors.MultiError(errs...)
+	if err == nil {
+		return nil
+	}
+	return writeJSON(rw, errorResponse(err))
+}
+
+// errorResponse builds the error envelope for err. An ErrorList lists every
+// member under "errors" and takes the most severe status. Any other error
+// takes status and message from its AppError and lists the members of an
+// ErrorList in its chain, such as validation field errors.
+func errorResponse(err error) Response {
+	list, ok := err.(*errors.ErrorList)
+	if !ok {
+		appErr := toAppError(err)
+		resp := Response{Status: appErr.HTTPStatus(), Error: true, Message: appErr.UserMsg}
+		if errors.As(err, &list) {
+			resp.Errors = list.AppErrors()
+		}
+		return resp
+	}
+	appErrs := list.AppErrors()
+	messages := make([]string, len(appErrs))
+	for i, appErr := range appErrs {
+		messages[i] = appErr.UserMsg
+	}
+	return Response{
+		Status:  list.HTTPStatus(),
+		Error:   true,
+		Message: strings.Join(messages, "; "),
+		Errors:  appErrs,
+	}
 }
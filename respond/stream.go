@@ -0,0 +1,256 @@
+package respond
+
+import (
+	"bufio"
+	"encoding/json"
+	stderrors "errors"
+	"iter"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NDJSONContentType is the media type of newline-delimited JSON streams.
+const NDJSONContentType = "application/x-ndjson"
+
+// NDJSON streams every item of seq as one JSON document per line, flushing
+// after each. It stops and returns the context error when the client
+// disconnects. Any server write deadline is lifted for the stream.
+func NDJSON[T any](rw http.ResponseWriter, r *http.Request, seq iter.Seq[T]) error {
+	rc := http.NewResponseController(rw)
+	clearWriteDeadline(rc)
+
+	h := rw.Header()
+	h.Set("Content-Type", NDJSONContentType)
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Content-Type-Options", "nosniff")
+	rw.WriteHeader(http.StatusOK)
+
+	ctx := r.Context()
+	enc := json.NewEncoder(rw)
+	for item := range seq {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+		if err := flush(rc); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// Event is a single Server-Sent Event. Data strings are sent as is (split
+// over several data lines if they contain newlines); any other value is
+// sent as JSON.
+type Event struct {
+	ID    string
+	Event string
+	Data  any
+	Retry time.Duration
+}
+
+// SSEOptions configures an SSE stream.
+type SSEOptions struct {
+	// Retry is sent once at the start as the client reconnection delay.
+	Retry time.Duration
+	// Heartbeat sends a comment line at this interval to keep idle
+	// connections open through proxies; 0 disables heartbeats.
+	Heartbeat time.Duration
+}
+
+// SSE writes a text/event-stream response. It is safe for concurrent use.
+type SSE struct {
+	mu          sync.Mutex
+	w           *bufio.Writer
+	rc          *http.ResponseController
+	done        <-chan struct{}
+	stop        chan struct{}
+	closeOnce   sync.Once
+	lastEventID string
+}
+
+// NewSSE starts an event stream on rw. The Last-Event-ID request header
+// is available through LastEventID so handlers can resume after it. The
+// stream ends when the request context is done or Close is called.
+func NewSSE(rw http.ResponseWriter, r *http.Request, opts SSEOptions) (*SSE, error) {
+	rc := http.NewResponseController(rw)
+	clearWriteDeadline(rc)
+
+	h := rw.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no")
+	rw.WriteHeader(http.StatusOK)
+
+	s := &SSE{
+		w:           bufio.NewWriter(rw),
+		rc:          rc,
+		done:        r.Context().Done(),
+		stop:        make(chan struct{}),
+		lastEventID: r.Header.Get("Last-Event-ID"),
+	}
+	if opts.Retry > 0 {
+		s.w.WriteString("retry: " + strconv.FormatInt(opts.Retry.Milliseconds(), 10) + "\n\n")
+	}
+	if err := s.flush(); err != nil {
+		return nil, err
+	}
+	if opts.Heartbeat > 0 {
+		go s.heartbeat(opts.Heartbeat)
+	}
+	return s, nil
+}
+
+// LastEventID returns the Last-Event-ID sent by a reconnecting client.
+func (s *SSE) LastEventID() string {
+	return s.lastEventID
+}
+
+// Done is closed when the client disconnects.
+func (s *SSE) Done() <-chan struct{} {
+	return s.done
+}
+
+// Send writes and flushes e. It returns an error once the client has
+// disconnected or the stream was closed.
+func (s *SSE) Send(e Event) error {
+	var b strings.Builder
+	if e.ID != "" {
+		b.WriteString("id: " + singleLine(e.ID) + "\n")
+	}
+	if e.Event != "" {
+		b.WriteString("event: " + singleLine(e.Event) + "\n")
+	}
+	if e.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(e.Retry.Milliseconds(), 10) + "\n")
+	}
+	data, err := eventData(e.Data)
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(data, "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteByte('\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.done:
+		return errStreamClosed
+	case <-s.stop:
+		return errStreamClosed
+	default:
+	}
+	s.w.WriteString(b.String())
+	return s.flush()
+}
+
+// Close stops the heartbeat and rejects further sends. Call it before the
+// handler returns; once Close returns nothing more is written to the stream.
+func (s *SSE) Close() {
+	s.closeOnce.Do(func() { close(s.stop) })
+	s.mu.Lock()
+	s.mu.Unlock()
+}
+
+// StreamSSE sends every item of seq as an event built by toEvent and
+// returns when seq is exhausted or the client disconnects. To resume a
+// stream, build seq from the request's Last-Event-ID header.
+func StreamSSE[T any](rw http.ResponseWriter, r *http.Request, opts SSEOptions, seq iter.Seq[T], toEvent func(T) Event) error {
+	s, err := NewSSE(rw, r, opts)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	for item := range seq {
+		if err := s.Send(toEvent(item)); err != nil {
+			if stderrors.Is(err, errStreamClosed) {
+				return r.Context().Err()
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+var errStreamClosed = stderrors.New("respond: event stream closed")
+
+func (s *SSE) heartbeat(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if err := s.beat(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *SSE) beat() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.stop:
+		return errStreamClosed
+	default:
+	}
+	s.w.WriteString(": heartbeat\n\n")
+	return s.flush()
+}
+
+func (s *SSE) flush() error {
+	if err := s.w.Flush(); err != nil {
+		return err
+	}
+	return flush(s.rc)
+}
+
+func eventData(v any) (string, error) {
+	switch val := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return normalizeNewlines(val), nil
+	case []byte:
+		return normalizeNewlines(string(val)), nil
+	default:
+		b, err := json.Marshal(val)
+		return string(b), err
+	}
+}
+
+// normalizeNewlines turns every SSE line terminator (CRLF, CR or LF) into
+// LF so multi-line data is split into data fields and cannot end the event.
+func normalizeNewlines(s string) string {
+	return strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(s)
+}
+
+func singleLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+// flush flushes rc, ignoring writers that cannot flush.
+func flush(rc *http.ResponseController) error {
+	if err := rc.Flush(); err != nil && !stderrors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// clearWriteDeadline lifts the server's WriteTimeout for a long-lived stream.
+func clearWriteDeadline(rc *http.ResponseController) {
+	_ = rc.SetWriteDeadline(time.Time{})
+}
@@ -0,0 +1,180 @@
+package respond
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/LooneY2K/common-pkg-svc/errors"
+)
+
+// fallbackBody is written when the response body cannot be encoded.
+// It is a pre-encoded Response so the fallback itself cannot fail to marshal.
+var fallbackBody = []byte(`{"status":500,"error":true,"message":"an error occurred"}` + "\n")
+
+// Writer builds a response: headers, cookies and an optional ETag are
+// collected first and the body is encoded into a buffer before anything is
+// sent, so the status and headers always match what was actually written.
+type Writer struct {
+	rw            http.ResponseWriter
+	req           *http.Request
+	header        http.Header
+	cookies       []*http.Cookie
+	etag          string
+	contentType   string
+	codec         Codec
+	notAcceptable bool
+}
+
+// NewWriter returns a Writer for rw that encodes JSON by default.
+func NewWriter(rw http.ResponseWriter) *Writer {
+	return &Writer{
+		rw:     rw,
+		header: make(http.Header),
+		codec:  JSONCodec,
+	}
+}
+
+// Header adds a response header.
+func (w *Writer) Header(key, value string) *Writer {
+	w.header.Add(key, value)
+	return w
+}
+
+// Cookie adds a Set-Cookie header.
+func (w *Writer) Cookie(c *http.Cookie) *Writer {
+	w.cookies = append(w.cookies, c)
+	return w
+}
+
+// ETag sets the entity tag. Unquoted tags are quoted; weak tags (W/"...")
+// are kept as is. Combined with Request, a matching If-None-Match on a
+// successful response yields 304 Not Modified without a body.
+func (w *Writer) ETag(tag string) *Writer {
+	if tag != "" && !strings.HasPrefix(tag, `"`) && !strings.HasPrefix(tag, `W/"`) {
+		tag = strconv.Quote(tag)
+	}
+	w.etag = tag
+	return w
+}
+
+// Request attaches the inbound request for conditional (If-None-Match) handling.
+func (w *Writer) Request(r *http.Request) *Writer {
+	w.req = r
+	return w
+}
+
+// ContentType overrides the Content-Type header, which otherwise comes
+// from the codec.
+func (w *Writer) ContentType(ct string) *Writer {
+	w.contentType = ct
+	return w
+}
+
+// Codec sets the codec used by Encode and Respond.
+func (w *Writer) Codec(c Codec) *Writer {
+	w.codec = c
+	return w
+}
+
+// Negotiate attaches r (as Request does) and selects the codec from its
+// Accept header. If no registered codec is acceptable, Encode and Respond
+// write a 406 Not Acceptable envelope in JSON instead.
+func (w *Writer) Negotiate(r *http.Request) *Writer {
+	w.req = r
+	c, ok := NegotiateCodec(r)
+	if !ok {
+		w.notAcceptable = true
+		return w
+	}
+	w.codec = c
+	return w
+}
+
+// JSON encodes v as JSON regardless of the negotiated codec.
+func (w *Writer) JSON(status int, v any) *errors.AppError {
+	w.codec = JSONCodec
+	w.notAcceptable = false
+	return w.Encode(status, v)
+}
+
+// Encode encodes v with the writer's codec and writes it with status.
+// If encoding fails, a 500 JSON envelope is written instead and the
+// encoding error is returned.
+func (w *Writer) Encode(status int, v any) *errors.AppError {
+	if w.notAcceptable {
+		w.codec = JSONCodec
+		w.etag = ""
+		status = http.StatusNotAcceptable
+		v = Response{Status: status, Error: true, Message: http.StatusText(status)}
+	}
+	var buf bytes.Buffer
+	if err := w.codec.Encode(&buf, v); err != nil {
+		w.contentType = JSONCodec.ContentType()
+		w.etag = ""
+		w.write(http.StatusInternalServerError, fallbackBody)
+		return errors.NewFromError(err, errors.CodeInternal, "")
+	}
+	return w.write(status, buf.Bytes())
+}
+
+// Respond writes the Response envelope with the writer's codec.
+func (w *Writer) Respond(response Response) *errors.AppError {
+	return w.Encode(response.Status, response)
+}
+
+// Bytes writes body with status as is, using the configured Content-Type.
+func (w *Writer) Bytes(status int, body []byte) *errors.AppError {
+	return w.write(status, body)
+}
+
+func (w *Writer) write(status int, body []byte) *errors.AppError {
+	h := w.rw.Header()
+	for k, vs := range w.header {
+		for _, v := range vs {
+			h.Add(k, v)
+		}
+	}
+	for _, c := range w.cookies {
+		http.SetCookie(w.rw, c)
+	}
+	if w.etag != "" {
+		h.Set("ETag", w.etag)
+		if w.notModified(status) {
+			w.rw.WriteHeader(http.StatusNotModified)
+			return nil
+		}
+	}
+	if w.contentType != "" {
+		h.Set("Content-Type", w.contentType)
+	} else if w.codec != nil {
+		h.Set("Content-Type", w.codec.ContentType())
+	}
+	h.Set("Content-Length", strconv.Itoa(len(body)))
+	w.rw.WriteHeader(status)
+	if _, err := w.rw.Write(body); err != nil {
+		return errors.NewFromError(err, errors.CodeNetwork, "")
+	}
+	return nil
+}
+
+func (w *Writer) notModified(status int) bool {
+	if w.req == nil || status < 200 || status > 299 {
+		return false
+	}
+	if w.req.Method != http.MethodGet && w.req.Method != http.MethodHead {
+		return false
+	}
+	inm := w.req.Header.Get("If-None-Match")
+	if inm == "" {
+		return false
+	}
+	for _, tag := range strings.Split(inm, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(w.etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
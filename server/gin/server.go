@@ -1,53 +1,34 @@
+// Package gin adapts gin engines to the server package.
 package gin
 
 import (
 	"context"
-	"fmt"
-	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
-	"time"
 
 	"github.com/LooneY2K/common-pkg-svc/server"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
-type ServerConfig struct {
-	IdleTimeout  int
-	ReadTimeout  int
-	WriteTimeout int
-	ShutdownWait int
-	Port         int
+// ServerConfig is server.ServerConfig, kept so existing callers compile.
+type ServerConfig = server.ServerConfig
+
+// New returns a server.Server for router. The engine's Handler is used so
+// gin settings such as UseH2C apply.
+func New(router *gin.Engine, config server.ServerConfig, opts ...server.Option) *server.Server {
+	return server.New(router.Handler(), config, opts...)
 }
 
-func StartAndGracefulShutdown(ctx context.Context, lgr *zap.SugaredLogger, router *gin.Engine, config server.ServerConfig) {
+// Run serves router with server.Run.
+func Run(ctx context.Context, lgr *zap.SugaredLogger, router *gin.Engine, config server.ServerConfig) error {
+	return New(router, config, server.WithLogger(lgr)).Run(ctx)
+}
 
-	s := &http.Server{
-		Addr:         fmt.Sprintf("%s%d", ":", config.Port),
-		IdleTimeout:  time.Duration(config.IdleTimeout) * time.Second,
-		ReadTimeout:  time.Duration(config.ReadTimeout) * time.Second,
-		WriteTimeout: time.Duration(config.WriteTimeout) * time.Second,
-		Handler:      router,
-	}
-	go func() {
-		lgr.Info("starting server on port: ", zap.Int("port", config.Port))
-		err := s.ListenAndServe()
-		if err != nil {
-			lgr.Error("failed to start server", zap.Error(err))
-		}
-	}()
-	signalChan := make(chan os.Signal, 1)
-	signal.Notify(signalChan, os.Interrupt, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
-	// wait indefinitely until we receive an interrupt signal
-	sig := <-signalChan
-	lgr.Info("Received terminate, gracefully shutting down: ", zap.String("signal", sig.String()))
-	ctx, cancel := context.WithTimeout(ctx, time.Duration(config.ShutdownWait)*time.Second)
-	err := s.Shutdown(ctx)
-	if err != nil {
-		lgr.Error("failed to shutdown server", zap.Error(err))
-		panic("server shutdown failure")
+// StartAndGracefulShutdown runs router until ctx is cancelled or a
+// shutdown signal arrives and logs any error instead of returning it.
+// opts can add lifecycle hooks.
+func StartAndGracefulShutdown(ctx context.Context, lgr *zap.SugaredLogger, router *gin.Engine, config server.ServerConfig, opts ...server.Option) {
+	opts = append([]server.Option{server.WithLogger(lgr)}, opts...)
+	if err := New(router, config, opts...).Run(ctx); err != nil {
+		lgr.Errorw("server stopped with error", "error", err)
 	}
-	cancel()
 }
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"time"
+
+	"github.com/LooneY2K/common-pkg-svc/errors"
+)
+
+// Runner is a background task run by a Group. It must return once ctx is
+// cancelled; returning context.Canceled then is not treated as a failure.
+type Runner func(ctx context.Context) error
+
+// Group runs several Servers and Runners as one unit. Members start in the
+// order they were added; when one fails, the context is cancelled or a
+// shutdown signal arrives, all are stopped in reverse order within a
+// shared shutdown budget.
+type Group struct {
+	options
+	shutdownWait time.Duration
+	members      []member
+}
+
+type member struct {
+	name   string
+	server *Server
+	start  func(ctx context.Context) error
+	stop   func(ctx context.Context) error
+	done   func() <-chan struct{}
+	err    func() error
+}
+
+// NewGroup returns an empty Group that allows shutdownWait in total for
+// stopping all of its members. Zero or less waits for them without limit.
+func NewGroup(shutdownWait time.Duration, opts ...Option) *Group {
+	return &Group{options: newOptions(opts), shutdownWait: shutdownWait}
+}
+
+// Add registers s under name. Its lifecycle hooks join the Group's, in the
+// order servers are added, the longest drain delay applies and its restart
+// signals are added to the Group's. Its own logger, signals and
+// ShutdownWait are ignored in favour of the Group's.
+func (g *Group) Add(name string, s *Server) *Group {
+	g.onStart = append(g.onStart, s.onStart...)
+	g.beforeShutdown = append(g.beforeShutdown, s.beforeShutdown...)
+	g.onShutdown = append(g.onShutdown, s.onShutdown...)
+	g.drainDelay = max(g.drainDelay, s.drainDelayOrConfig())
+	for _, sig := range s.restartSignals {
+		if !slices.Contains(g.restartSignals, sig) {
+			g.restartSignals = append(g.restartSignals, sig)
+		}
+	}
+	g.members = append(g.members, member{
+		name:   name,
+		server: s,
+		start:  func(context.Context) error { return s.start() },
+		stop:   s.shutdown,
+		done:   func() <-chan struct{} { return s.done },
+		err:    func() error { return s.err },
+	})
+	return g
+}
+
+// Go registers r under name. r runs with a context that keeps the values
+// of the context passed to Run and is cancelled when r's turn to stop comes.
+func (g *Group) Go(name string, r Runner) *Group {
+	var (
+		cancel context.CancelFunc
+		done   = make(chan struct{})
+		runErr error
+	)
+	g.members = append(g.members, member{
+		name: name,
+		start: func(ctx context.Context) error {
+			var runCtx context.Context
+			runCtx, cancel = context.WithCancel(context.WithoutCancel(ctx))
+			go func() {
+				defer close(done)
+				if err := r(runCtx); err != nil && !(runCtx.Err() != nil && errors.Is(err, context.Canceled)) {
+					runErr = err
+				}
+			}()
+			return nil
+		},
+		stop: func(ctx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return runErr
+			case <-ctx.Done():
+				return fmt.Errorf("runner did not stop: %w", ctx.Err())
+			}
+		},
+		done: func() <-chan struct{} { return done },
+		err:  func() error { return runErr },
+	})
+	return g
+}
+
+// Run runs the OnStart hooks, starts every member and blocks until one
+// fails, ctx is cancelled, a shutdown signal arrives or a restart handed
+// the listeners over to a new process (see WithRestart). It then runs the
+// BeforeShutdown hooks, waits out the drain delay, stops the started
+// members in reverse order and runs the OnShutdown hooks. Every failure,
+// hook and shutdown error is returned combined, or nil.
+func (g *Group) Run(ctx context.Context) error {
+	signalChan := make(chan os.Signal, 1)
+	if signals := g.shutdownSignals(); len(signals) > 0 {
+		signal.Notify(signalChan, signals...)
+		defer signal.Stop(signalChan)
+	}
+	restartChan := make(chan os.Signal, 1)
+	if len(g.restartSignals) > 0 {
+		signal.Notify(restartChan, g.restartSignals...)
+		defer signal.Stop(restartChan)
+	}
+
+	var errs []error
+	failed := make(chan string, len(g.members))
+	started := 0
+	hookCtx := context.WithoutCancel(ctx)
+	if errs = runHooks(hookCtx, "start", g.onStart); len(errs) == 0 {
+		for _, m := range g.members {
+			if err := m.start(ctx); err != nil {
+				errs = append(errs, named(m.name, err))
+				break
+			}
+			started++
+			go func() {
+				<-m.done()
+				if m.err() != nil {
+					failed <- m.name
+				}
+			}()
+		}
+	}
+
+	if len(errs) == 0 {
+		notifyParentReady()
+	wait:
+		for {
+			select {
+			case name := <-failed:
+				g.lgr.Errorw("member failed, shutting down", "member", name)
+			case sig := <-signalChan:
+				g.lgr.Infow("received signal, gracefully shutting down", "signal", sig.String())
+			case sig := <-restartChan:
+				g.lgr.Infow("received signal, restarting", "signal", sig.String())
+				if err := g.restart(ctx); err != nil {
+					g.lgr.Errorw("restart failed, still serving", "error", err)
+					continue
+				}
+			case <-ctx.Done():
+				g.lgr.Infow("context done, gracefully shutting down", "reason", context.Cause(ctx).Error())
+			}
+			break wait
+		}
+	}
+
+	errs = append(errs, runHooks(hookCtx, "before shutdown", g.beforeShutdown)...)
+	if started > 0 && g.drainDelay > 0 {
+		g.lgr.Infow("draining before shutdown", "delay", g.drainDelay.String())
+		time.Sleep(g.drainDelay)
+	}
+
+	shutdownCtx, cancel := context.WithCancel(hookCtx)
+	if g.shutdownWait > 0 {
+		shutdownCtx, cancel = context.WithTimeout(hookCtx, g.shutdownWait)
+	}
+	defer cancel()
+	for i := started - 1; i >= 0; i-- {
+		m := g.members[i]
+		if err := m.stop(shutdownCtx); err != nil {
+			errs = append(errs, named(m.name, err))
+		}
+	}
+	errs = append(errs, runHooks(hookCtx, "shutdown", reversed(g.onShutdown))...)
+	return errors.MultiError(errs...)
+}
+
+// shutdownSignals returns the signals that stop the group, leaving out
+// those that trigger a restart.
+func (g *Group) shutdownSignals() []os.Signal {
+	return slices.DeleteFunc(slices.Clone(g.signals), func(sig os.Signal) bool {
+		return slices.Contains(g.restartSignals, sig)
+	})
+}
+
+func named(name string, err error) error {
+	if name == "" {
+		return err
+	}
+	return fmt.Errorf("%s: %w", name, err)
+}
+
+func reversed(hooks []Hook) []Hook {
+	out := make([]Hook, len(hooks))
+	for i, h := range hooks {
+		out[len(hooks)-1-i] = h
+	}
+	return out
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/LooneY2K/common-pkg-svc/health"
+)
+
+// Hook is a named callback run at a point in the server lifecycle. A
+// positive Timeout bounds each call; otherwise the hook runs until it returns.
+type Hook struct {
+	Name    string
+	Fn      func(ctx context.Context) error
+	Timeout time.Duration
+}
+
+// OnStart registers hooks that run in order before any member starts, for
+// example to open database pools. If one fails nothing is started and the
+// shutdown sequence runs at once.
+func OnStart(hooks ...Hook) Option {
+	return func(o *options) {
+		o.onStart = append(o.onStart, hooks...)
+	}
+}
+
+// BeforeShutdown registers hooks that run in order as soon as shutdown
+// begins and before the drain delay, for example to fail readiness checks
+// so load balancers stop routing new requests.
+func BeforeShutdown(hooks ...Hook) Option {
+	return func(o *options) {
+		o.beforeShutdown = append(o.beforeShutdown, hooks...)
+	}
+}
+
+// OnShutdown registers hooks that run in reverse order after every member
+// has stopped, for example to close database pools.
+func OnShutdown(hooks ...Hook) Option {
+	return func(o *options) {
+		o.onShutdown = append(o.onShutdown, hooks...)
+	}
+}
+
+// WithDrainDelay keeps serving for d after the BeforeShutdown hooks ran and
+// before members are stopped, giving load balancers time to notice.
+// It overrides ServerConfig.DrainDelay.
+func WithDrainDelay(d time.Duration) Option {
+	return func(o *options) {
+		o.drainDelay = d
+	}
+}
+
+// WithHealth marks reg not ready as soon as shutdown begins, ahead of the
+// drain delay and any other BeforeShutdown hooks registered after it.
+func WithHealth(reg *health.Registry) Option {
+	return BeforeShutdown(Hook{Name: "readiness", Fn: func(context.Context) error {
+		reg.SetReady(false)
+		return nil
+	}})
+}
+
+// runHooks calls each hook with ctx bounded by its Timeout and returns the
+// errors of those that failed.
+func runHooks(ctx context.Context, stage string, hooks []Hook) []error {
+	var errs []error
+	for _, h := range hooks {
+		if err := runHook(ctx, h); err != nil {
+			errs = append(errs, fmt.Errorf("%s hook %q: %w", stage, h.Name, err))
+		}
+	}
+	return errs
+}
+
+func runHook(ctx context.Context, h Hook) error {
+	if h.Timeout <= 0 {
+		return h.Fn(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.Timeout)
+	defer cancel()
+
+	// Run the hook aside so a hook that ignores ctx cannot outlive its timeout.
+	result := make(chan error, 1)
+	go func() { result <- h.Fn(ctx) }()
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
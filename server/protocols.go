@@ -0,0 +1,74 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+// configureProtocols applies the protocol settings and limits of the
+// config to the HTTP server.
+func (s *Server) configureProtocols() error {
+	if len(s.config.Protocols) > 0 {
+		protocols := new(http.Protocols)
+		for _, name := range s.config.Protocols {
+			switch name {
+			case "http1":
+				protocols.SetHTTP1(true)
+			case "h2":
+				protocols.SetHTTP2(true)
+			case "h2c":
+				protocols.SetUnencryptedHTTP2(true)
+			default:
+				return fmt.Errorf("server: unknown protocol %q, want http1, h2 or h2c", name)
+			}
+		}
+		s.srv.Protocols = protocols
+	}
+	if s.config.MaxConcurrentStreams < 0 || s.config.MaxHeaderBytes < 0 {
+		return fmt.Errorf("server: MaxConcurrentStreams and MaxHeaderBytes must not be negative")
+	}
+	s.srv.MaxHeaderBytes = s.config.MaxHeaderBytes
+	if s.config.MaxConcurrentStreams > 0 {
+		s.srv.HTTP2 = &http.HTTP2Config{MaxConcurrentStreams: s.config.MaxConcurrentStreams}
+	}
+	if s.config.HTTP3 && s.srv.TLSConfig == nil {
+		return fmt.Errorf("server: HTTP3 requires TLS")
+	}
+	return nil
+}
+
+// listenHTTP3 opens a UDP socket on the port of the TCP listener ln, or
+// takes the one handed over by a restart, and prepares the HTTP/3 server.
+// Responses over TCP advertise it in Alt-Svc.
+func (s *Server) listenHTTP3(ln net.Listener) error {
+	tcpAddr, ok := ln.Addr().(*net.TCPAddr)
+	if !ok {
+		return fmt.Errorf("server: HTTP3 needs a TCP listener, got %s", ln.Addr().Network())
+	}
+	s.udp = takeInheritedPacketConn()
+	if s.udp == nil {
+		udp, err := net.ListenUDP("udp", &net.UDPAddr{IP: tcpAddr.IP, Port: tcpAddr.Port, Zone: tcpAddr.Zone})
+		if err != nil {
+			return fmt.Errorf("server: listen on udp %s: %w", tcpAddr, err)
+		}
+		s.udp = udp
+	}
+	s.h3 = &http3.Server{
+		Handler:        s.handler,
+		TLSConfig:      s.srv.TLSConfig,
+		Port:           tcpAddr.Port,
+		MaxHeaderBytes: s.config.MaxHeaderBytes,
+		IdleTimeout:    time.Duration(s.config.IdleTimeout) * time.Second,
+		QUICConfig:     &quic.Config{MaxIncomingStreams: int64(s.config.MaxConcurrentStreams)},
+	}
+	s.srv.Handler = http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		s.h3.SetQUICHeaders(rw.Header())
+		s.handler.ServeHTTP(rw, r)
+	})
+	return nil
+}
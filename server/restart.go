@@ -0,0 +1,222 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Environment variables describing the sockets handed to a restarted
+// process. Listeners are passed as file descriptors 3 onwards, in the
+// order their Servers were added and each followed by its HTTP/3 socket,
+// if any, then the readiness pipe.
+const (
+	ListenFDsEnv = "SERVER_LISTEN_FDS"
+	ReadyFDEnv   = "SERVER_READY_FD"
+)
+
+// RestartTimeout bounds how long a restarting process waits for its
+// replacement to report ready before giving up and carrying on serving.
+const RestartTimeout = time.Minute
+
+// WithRestart enables zero-downtime restarts: on one of signals (default
+// SIGUSR2 and SIGHUP) the process starts a new copy of itself that
+// inherits the listening sockets, waits until it is serving, then shuts
+// itself down gracefully. These signals no longer trigger a plain shutdown.
+func WithRestart(signals ...os.Signal) Option {
+	return func(o *options) {
+		if len(signals) == 0 {
+			signals = defaultRestartSignals
+		}
+		o.restartSignals = signals
+	}
+}
+
+var inherited struct {
+	once  sync.Once
+	mu    sync.Mutex
+	files []*os.File
+	ready *os.File
+}
+
+// loadInherited reads the sockets handed over by a restarting parent and
+// clears the environment so they are not handed on by mistake.
+func loadInherited() {
+	inherited.once.Do(func() {
+		if n, err := strconv.Atoi(os.Getenv(ListenFDsEnv)); err == nil {
+			for fd := 3; fd < 3+n; fd++ {
+				inherited.files = append(inherited.files, os.NewFile(uintptr(fd), "listener"))
+			}
+		}
+		if fd, err := strconv.Atoi(os.Getenv(ReadyFDEnv)); err == nil {
+			inherited.ready = os.NewFile(uintptr(fd), "ready")
+		}
+		os.Unsetenv(ListenFDsEnv)
+		os.Unsetenv(ReadyFDEnv)
+	})
+}
+
+// takeInherited returns the next socket handed over by the parent process,
+// or nil.
+func takeInherited() *os.File {
+	loadInherited()
+	inherited.mu.Lock()
+	defer inherited.mu.Unlock()
+	if len(inherited.files) == 0 {
+		return nil
+	}
+	f := inherited.files[0]
+	inherited.files = inherited.files[1:]
+	return f
+}
+
+// takeInheritedListener returns the next listener handed over by the
+// parent process, or nil.
+func takeInheritedListener() net.Listener {
+	f := takeInherited()
+	if f == nil {
+		return nil
+	}
+	defer f.Close()
+	ln, err := net.FileListener(f)
+	if err != nil {
+		return nil
+	}
+	return ln
+}
+
+// takeInheritedPacketConn returns the next UDP socket handed over by the
+// parent process, or nil.
+func takeInheritedPacketConn() net.PacketConn {
+	f := takeInherited()
+	if f == nil {
+		return nil
+	}
+	defer f.Close()
+	conn, err := net.FilePacketConn(f)
+	if err != nil {
+		return nil
+	}
+	return conn
+}
+
+// notifyParentReady tells a restarting parent that this process is serving.
+func notifyParentReady() {
+	loadInherited()
+	inherited.mu.Lock()
+	defer inherited.mu.Unlock()
+	if inherited.ready != nil {
+		inherited.ready.Write([]byte{1})
+		inherited.ready.Close()
+		inherited.ready = nil
+	}
+}
+
+// restart starts a copy of the running executable with the servers'
+// listeners and waits until it reports ready. On error the new process is
+// stopped and the current one keeps serving.
+func (g *Group) restart(ctx context.Context) error {
+	var files []*os.File
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	for _, m := range g.members {
+		if m.server == nil {
+			continue
+		}
+		f, err := socketFile(m.server.ln)
+		if err != nil {
+			return fmt.Errorf("server: hand off %s listener: %w", m.name, err)
+		}
+		files = append(files, f)
+		if m.server.udp != nil {
+			f, err := socketFile(m.server.udp)
+			if err != nil {
+				return fmt.Errorf("server: hand off %s http3 socket: %w", m.name, err)
+			}
+			files = append(files, f)
+		}
+	}
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("server: create readiness pipe: %w", err)
+	}
+	defer readyR.Close()
+	listenerCount := len(files)
+	files = append(files, readyW)
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("server: find executable: %w", err)
+	}
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(slices.DeleteFunc(os.Environ(), func(kv string) bool {
+		return hasEnvKey(kv, ListenFDsEnv) || hasEnvKey(kv, ReadyFDEnv)
+	}), ListenFDsEnv+"="+strconv.Itoa(listenerCount), ReadyFDEnv+"="+strconv.Itoa(3+listenerCount))
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("server: start new process: %w", err)
+	}
+	readyW.Close()
+	files = files[:listenerCount]
+	g.lgr.Infow("started new process, waiting for it to be ready", "pid", cmd.Process.Pid)
+
+	ready := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		if _, err := io.ReadFull(readyR, buf); err != nil {
+			ready <- fmt.Errorf("server: new process exited before it was ready")
+			return
+		}
+		ready <- nil
+	}()
+	timer := time.NewTimer(RestartTimeout)
+	defer timer.Stop()
+	select {
+	case err = <-ready:
+	case <-timer.C:
+		err = fmt.Errorf("server: new process not ready after %s", RestartTimeout)
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		cmd.Process.Kill()
+		go cmd.Wait()
+		return err
+	}
+
+	// The new process owns the sockets now; closing ours must not unlink them.
+	for _, m := range g.members {
+		if m.server != nil {
+			if ul, ok := m.server.ln.(*net.UnixListener); ok {
+				ul.SetUnlinkOnClose(false)
+			}
+		}
+	}
+	g.lgr.Infow("new process is ready, handing over", "pid", cmd.Process.Pid)
+	cmd.Process.Release()
+	return nil
+}
+
+func socketFile(sock any) (*os.File, error) {
+	fl, ok := sock.(interface{ File() (*os.File, error) })
+	if !ok {
+		return nil, fmt.Errorf("socket %T cannot be handed over", sock)
+	}
+	return fl.File()
+}
+
+func hasEnvKey(kv, key string) bool {
+	return len(kv) > len(key) && kv[:len(key)] == key && kv[len(key)] == '='
+}
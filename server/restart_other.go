@@ -0,0 +1,8 @@
+//go:build !unix
+
+package server
+
+import "os"
+
+// Restarts rely on inheriting file descriptors, which needs a Unix system.
+var defaultRestartSignals []os.Signal
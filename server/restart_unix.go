@@ -0,0 +1,10 @@
+//go:build unix
+
+package server
+
+import (
+	"os"
+	"syscall"
+)
+
+var defaultRestartSignals = []os.Signal{syscall.SIGUSR2, syscall.SIGHUP}
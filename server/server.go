@@ -2,14 +2,18 @@ package server
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
-	"os/signal"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/quic-go/quic-go/http3"
 	"go.uber.org/zap"
 )
 
@@ -19,29 +23,340 @@ type ServerConfig struct {
 	WriteTimeout int
 	ShutdownWait int
 	Port         int
+	// DrainDelay is how long to keep serving once shutdown begins, in seconds.
+	DrainDelay int
+
+	// Addr is the address to listen on, e.g. "127.0.0.1:8080" or a socket
+	// path for the "unix" network. It overrides Port; with neither set, or
+	// Port 0, an ephemeral port is picked. Use Server.Addr for the result.
+	Addr string
+	// Network is "tcp" (default), "tcp4", "tcp6" or "unix".
+	Network string
+	// Listener, when set, is served instead of opening one, for example a
+	// socket inherited from systemd. Addr, Network and Port are ignored.
+	// After a restart (see WithRestart) the socket handed over by the old
+	// process is served instead and Listener is closed.
+	Listener net.Listener
+
+	// CertFile and KeyFile enable HTTPS. Rotated files are picked up by new
+	// connections without a restart.
+	CertFile string
+	KeyFile  string
+	// ClientCAFile enables mutual TLS: clients must present a certificate
+	// signed by one of its CAs.
+	ClientCAFile string
+	// MinTLSVersion is "1.2" (default) or "1.3".
+	MinTLSVersion string
+	// CipherSuites restricts the TLS 1.2 cipher suites by name, e.g.
+	// "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256". Empty uses Go's defaults.
+	CipherSuites []string
+
+	// Protocols lists the protocols served over TCP: "http1", "h2" (HTTP/2
+	// over TLS) and "h2c" (HTTP/2 without TLS, prior knowledge only).
+	// Empty serves HTTP/1.1, plus HTTP/2 when TLS is enabled.
+	Protocols []string
+	// HTTP3 also serves HTTP/3 over QUIC on the same port over UDP and
+	// advertises it with Alt-Svc. It requires TLS and a TCP address.
+	HTTP3 bool
+	// MaxConcurrentStreams limits the streams per HTTP/2 or HTTP/3
+	// connection. Zero uses the defaults.
+	MaxConcurrentStreams int
+	// MaxHeaderBytes limits the size of request headers for every protocol.
+	// Zero uses http.DefaultMaxHeaderBytes.
+	MaxHeaderBytes int
 }
 
-func StartAndGracefullShutdown(lgr *zap.SugaredLogger, router *chi.Mux, config ServerConfig) {
-	s := &http.Server{
-		Addr:         fmt.Sprintf("%s%d", ":", config.Port),
-		IdleTimeout:  time.Duration(config.IdleTimeout) * time.Second,
-		ReadTimeout:  time.Duration(config.ReadTimeout) * time.Second,
-		WriteTimeout: time.Duration(config.WriteTimeout) * time.Second,
-		Handler:      router,
+// DefaultSignals end Run and trigger a graceful shutdown unless
+// WithSignals says otherwise.
+var DefaultSignals = []os.Signal{os.Interrupt, syscall.SIGHUP, syscall.SIGTERM, syscall.SIGQUIT}
+
+// Server runs an http.Handler from any router (chi, gin, http.ServeMux)
+// with graceful shutdown. Create it with New.
+type Server struct {
+	options
+	handler http.Handler
+	config  ServerConfig
+
+	started  atomic.Bool
+	startErr error
+
+	srv   *http.Server
+	certs *certReloader
+	h3    *http3.Server
+	udp   net.PacketConn
+	ln    net.Listener
+	ready chan struct{}
+	addr  net.Addr
+	done  chan struct{}
+	err   error
+}
+
+// options are shared by Server and Group.
+type options struct {
+	lgr            *zap.SugaredLogger
+	signals        []os.Signal
+	onStart        []Hook
+	beforeShutdown []Hook
+	onShutdown     []Hook
+	drainDelay     time.Duration
+	restartSignals []os.Signal
+}
+
+// Option configures a Server or a Group.
+type Option func(*options)
+
+// WithLogger sets the logger for lifecycle messages. The default discards them.
+func WithLogger(lgr *zap.SugaredLogger) Option {
+	return func(o *options) {
+		if lgr != nil {
+			o.lgr = lgr
+		}
 	}
-	go func() {
-		lgr.Info("starting server on port: ", config.Port)
-		err := s.ListenAndServe()
+}
+
+// WithSignals replaces the signals that trigger shutdown in Run. With no
+// signals, only context cancellation stops the server.
+func WithSignals(signals ...os.Signal) Option {
+	return func(o *options) {
+		o.signals = signals
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{lgr: zap.NewNop().Sugar(), signals: DefaultSignals}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// New returns a Server for handler configured by config and opts.
+func New(handler http.Handler, config ServerConfig, opts ...Option) *Server {
+	return &Server{
+		options: newOptions(opts),
+		handler: handler,
+		config:  config,
+		ready:   make(chan struct{}),
+	}
+}
+
+// Ready is closed once the server is listening or has failed to start;
+// Err tells which.
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Err returns the error that kept the server from starting, such as a port
+// already in use, or nil. It is only meaningful once Ready is closed.
+func (s *Server) Err() error {
+	select {
+	case <-s.ready:
+		return s.startErr
+	default:
+		return nil
+	}
+}
+
+// Addr returns the address the server is bound to, such as the port picked
+// for Port 0, or nil before it is listening or if it failed to start.
+func (s *Server) Addr() net.Addr {
+	select {
+	case <-s.ready:
+		return s.addr
+	default:
+		return nil
+	}
+}
+
+// Run serves until ctx is cancelled or a shutdown signal arrives, then
+// waits config.DrainDelay seconds and shuts down gracefully within
+// config.ShutdownWait seconds (0 waits without limit), running any
+// lifecycle hooks on the way. It returns the listen or serve error if the
+// server fails, the shutdown error if connections did not drain in time,
+// hook errors, and nil after a clean shutdown. A Server runs once; calling
+// Run again returns an error.
+func (s *Server) Run(ctx context.Context) error {
+	g := &Group{options: options{lgr: s.lgr, signals: s.signals}, shutdownWait: s.shutdownWait()}
+	return g.Add("", s).Run(ctx)
+}
+
+// drainDelayOrConfig returns the WithDrainDelay option, falling back to
+// config.DrainDelay.
+func (s *Server) drainDelayOrConfig() time.Duration {
+	if s.drainDelay != 0 {
+		return s.drainDelay
+	}
+	return time.Duration(s.config.DrainDelay) * time.Second
+}
+
+// start binds the listener and serves in the background. ready is closed
+// either way, with startErr set on failure. done is closed when serving
+// ends; err is nil if it ended because of shutdown.
+func (s *Server) start() (err error) {
+	if !s.started.CompareAndSwap(false, true) {
+		return fmt.Errorf("server: already started")
+	}
+	defer func() {
 		if err != nil {
-			lgr.Fatal(err)
+			s.startErr = err
+			close(s.ready)
 		}
 	}()
-	signalChan := make(chan os.Signal, 1)
-	signal.Notify(signalChan, os.Interrupt, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
-	// wait indefinitely until we don't receive an intrupt signal
-	sig := <-signalChan
-	lgr.Info("Received terminate, gracefully shutting down: ", sig)
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.ShutdownWait)*time.Second)
-	s.Shutdown(ctx)
-	cancel()
+	s.srv = &http.Server{
+		IdleTimeout:  time.Duration(s.config.IdleTimeout) * time.Second,
+		ReadTimeout:  time.Duration(s.config.ReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(s.config.WriteTimeout) * time.Second,
+		Handler:      s.handler,
+	}
+	if s.config.TLSEnabled() {
+		cfg, certs, err := tlsConfig(s.config, s.lgr)
+		if err != nil {
+			return err
+		}
+		s.srv.TLSConfig, s.certs = cfg, certs
+	}
+	if err := s.configureProtocols(); err != nil {
+		return err
+	}
+	ln, err := s.listen()
+	if err != nil {
+		return err
+	}
+	if s.config.HTTP3 {
+		if err := s.listenHTTP3(ln); err != nil {
+			ln.Close()
+			return err
+		}
+	}
+	s.ln = ln
+	s.srv.Addr = ln.Addr().String()
+	s.addr = ln.Addr()
+	if s.certs != nil {
+		s.certs.watch(certCheckInterval)
+	}
+	close(s.ready)
+
+	s.done = make(chan struct{})
+	s.lgr.Infow("starting server", "network", s.addr.Network(), "addr", s.srv.Addr, "tls", s.srv.TLSConfig != nil, "http3", s.h3 != nil)
+	var serveErr, h3Err error
+	var serving sync.WaitGroup
+	serving.Go(func() {
+		if err := s.serve(ln); !stderrors.Is(err, http.ErrServerClosed) {
+			serveErr = fmt.Errorf("server: serve: %w", err)
+			if s.h3 != nil {
+				s.h3.Close()
+			}
+		}
+	})
+	if s.h3 != nil {
+		serving.Go(func() {
+			if err := s.h3.Serve(s.udp); !stderrors.Is(err, http.ErrServerClosed) {
+				h3Err = fmt.Errorf("server: serve http3: %w", err)
+				// Stop serving TCP as well so the failure is noticed.
+				s.srv.Close()
+			}
+		})
+	}
+	go func() {
+		serving.Wait()
+		s.err = stderrors.Join(serveErr, h3Err)
+		close(s.done)
+	}()
+	return nil
+}
+
+func (s *Server) listen() (net.Listener, error) {
+	if ln := takeInheritedListener(); ln != nil {
+		if s.config.Listener != nil {
+			s.config.Listener.Close()
+		}
+		return ln, nil
+	}
+	if s.config.Listener != nil {
+		return s.config.Listener, nil
+	}
+	network, addr := s.config.Network, s.config.Addr
+	if network == "" {
+		network = "tcp"
+	}
+	if addr == "" {
+		addr = fmt.Sprintf("%s%d", ":", s.config.Port)
+	}
+	if network == "unix" {
+		removeStaleSocket(addr)
+	}
+	ln, err := net.Listen(network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("server: listen on %s %s: %w", network, addr, err)
+	}
+	return ln, nil
+}
+
+// removeStaleSocket deletes a Unix socket file left behind by a process
+// that exited without closing it. Sockets still accepting are kept.
+func removeStaleSocket(path string) {
+	fi, err := os.Stat(path)
+	if err != nil || fi.Mode()&os.ModeSocket == 0 {
+		return
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return
+	}
+	os.Remove(path)
+}
+
+func (s *Server) serve(ln net.Listener) error {
+	if s.srv.TLSConfig != nil {
+		return s.srv.ServeTLS(ln, "", "")
+	}
+	return s.srv.Serve(ln)
+}
+
+// shutdown stops accepting connections and waits for active ones to
+// finish until ctx is done. It returns the serve error, if any, too.
+func (s *Server) shutdown(ctx context.Context) error {
+	if s.certs != nil {
+		defer s.certs.close()
+	}
+	h3Done := make(chan error, 1)
+	if s.h3 != nil {
+		go func() {
+			defer s.udp.Close()
+			if err := s.h3.Shutdown(ctx); err != nil {
+				h3Done <- fmt.Errorf("server: shutdown http3: %w", err)
+				return
+			}
+			h3Done <- nil
+		}()
+	} else {
+		h3Done <- nil
+	}
+	if err := s.srv.Shutdown(ctx); err != nil {
+		return stderrors.Join(fmt.Errorf("server: shutdown: %w", err), <-h3Done)
+	}
+	if err := <-h3Done; err != nil {
+		return err
+	}
+	<-s.done
+	return s.err
+}
+
+func (s *Server) shutdownWait() time.Duration {
+	return time.Duration(s.config.ShutdownWait) * time.Second
+}
+
+// Run serves handler with New(handler, config, WithLogger(lgr)).Run(ctx).
+func Run(ctx context.Context, lgr *zap.SugaredLogger, handler http.Handler, config ServerConfig) error {
+	return New(handler, config, WithLogger(lgr)).Run(ctx)
+}
+
+// StartAndGracefullShutdown runs router until a shutdown signal arrives
+// and logs any error instead of returning it. opts can add lifecycle hooks
+// or WithRestart, which turns SIGHUP into a zero-downtime restart.
+func StartAndGracefullShutdown(lgr *zap.SugaredLogger, router *chi.Mux, config ServerConfig, opts ...Option) {
+	opts = append([]Option{WithLogger(lgr)}, opts...)
+	if err := New(router, config, opts...).Run(context.Background()); err != nil {
+		lgr.Errorw("server stopped with error", "error", err)
+	}
 }
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TLSEnabled reports whether config serves HTTPS.
+func (c ServerConfig) TLSEnabled() bool {
+	return c.CertFile != "" || c.KeyFile != ""
+}
+
+// tlsConfig builds the TLS configuration for config. The returned
+// certReloader serves the certificate; start its watch to pick up rotated
+// files.
+func tlsConfig(config ServerConfig, lgr *zap.SugaredLogger) (*tls.Config, *certReloader, error) {
+	if config.CertFile == "" || config.KeyFile == "" {
+		return nil, nil, fmt.Errorf("server: both CertFile and KeyFile are required for TLS")
+	}
+	reloader := &certReloader{certFile: config.CertFile, keyFile: config.KeyFile, lgr: lgr}
+	if err := reloader.load(); err != nil {
+		return nil, nil, err
+	}
+
+	cfg := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.getCertificate,
+	}
+	switch config.MinTLSVersion {
+	case "", "1.2":
+	case "1.3":
+		cfg.MinVersion = tls.VersionTLS13
+	default:
+		return nil, nil, fmt.Errorf("server: unsupported MinTLSVersion %q, want 1.2 or 1.3", config.MinTLSVersion)
+	}
+
+	if len(config.CipherSuites) > 0 {
+		ids, err := cipherSuites(config.CipherSuites)
+		if err != nil {
+			return nil, nil, err
+		}
+		cfg.CipherSuites = ids
+	}
+
+	if config.ClientCAFile != "" {
+		pem, err := os.ReadFile(config.ClientCAFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("server: read client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, nil, fmt.Errorf("server: no certificates found in client CA file %s", config.ClientCAFile)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, reloader, nil
+}
+
+// cipherSuites maps cipher suite names, as in tls.CipherSuiteName, to IDs.
+// Only secure suites are accepted; they apply to TLS 1.2, as TLS 1.3 suites
+// are not configurable.
+func cipherSuites(names []string) ([]uint16, error) {
+	known := make(map[string]uint16)
+	for _, cs := range tls.CipherSuites() {
+		known[cs.Name] = cs.ID
+	}
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := known[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("server: unknown or insecure cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// certCheckInterval is how often the watcher looks for rotated files.
+const certCheckInterval = time.Second
+
+// certReloader serves a key pair from disk. Its watch reloads the pair in
+// the background when the files' size or modification time change, so
+// rotated certificates are picked up by new connections without a restart
+// and handshakes never touch the disk. A pair that fails to load keeps the
+// previous one in use.
+type certReloader struct {
+	certFile, keyFile string
+	lgr               *zap.SugaredLogger
+
+	cert atomic.Pointer[tls.Certificate]
+	done chan struct{}
+	stop sync.Once
+
+	mu      sync.Mutex
+	version string
+}
+
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// watch checks the files every interval until close is called.
+func (r *certReloader) watch(interval time.Duration) {
+	r.done = make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				r.reload()
+			case <-r.done:
+				return
+			}
+		}
+	}()
+}
+
+// close stops the watcher started by watch, if any.
+func (r *certReloader) close() {
+	r.stop.Do(func() {
+		if r.done != nil {
+			close(r.done)
+		}
+	})
+}
+
+// reload loads the key pair again if the files changed since the last load.
+func (r *certReloader) reload() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	v, err := r.fileVersion()
+	if err != nil || v == r.version {
+		return
+	}
+	if err := r.loadLocked(v); err != nil {
+		r.lgr.Warnw("keeping previous TLS certificate", "error", err)
+		return
+	}
+	r.lgr.Infow("reloaded TLS certificate", "cert", r.certFile)
+}
+
+func (r *certReloader) load() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	v, err := r.fileVersion()
+	if err != nil {
+		return fmt.Errorf("server: stat TLS certificate: %w", err)
+	}
+	return r.loadLocked(v)
+}
+
+func (r *certReloader) loadLocked(version string) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("server: load TLS certificate: %w", err)
+	}
+	r.cert.Store(&cert)
+	r.version = version
+	return nil
+}
+
+// fileVersion identifies the current contents of both files.
+func (r *certReloader) fileVersion() (string, error) {
+	var b strings.Builder
+	for _, name := range []string{r.certFile, r.keyFile} {
+		fi, err := os.Stat(name)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%d:%s;", fi.Size(), fi.ModTime().Format(time.RFC3339Nano))
+	}
+	return b.String(), nil
+}
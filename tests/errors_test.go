@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/json"
 	stderrors "errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -174,6 +175,38 @@ func TestErrors_MarshalJSON_RedactsPII(t *testing.T) {
 	assert.Equal(t, "u1", meta["user_id"])
 }
 
+func TestErrors_MarshalJSON_RedactsNestedPII(t *testing.T) {
+	type profile struct {
+		Name  string `json:"name"`
+		Phone string `json:"phone"`
+	}
+	e := errors.New(errors.CodeValidation, "internal", "user",
+		errors.WithMetadata(map[string]any{
+			"user":    map[string]string{"password": "hunter2", "login": "alice"},
+			"profile": profile{Name: "Alice", Phone: "555-0100"},
+			"batch":   []profile{{Name: "Bob", Phone: "555-0101"}},
+		}),
+	)
+	b, err := json.Marshal(e)
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "hunter2")
+	assert.NotContains(t, string(b), "555-01")
+
+	var m struct {
+		Metadata struct {
+			User    map[string]any   `json:"user"`
+			Profile map[string]any   `json:"profile"`
+			Batch   []map[string]any `json:"batch"`
+		} `json:"metadata"`
+	}
+	require.NoError(t, json.Unmarshal(b, &m))
+	assert.Equal(t, "[REDACTED]", m.Metadata.User["password"])
+	assert.Equal(t, "alice", m.Metadata.User["login"])
+	assert.Equal(t, "[REDACTED]", m.Metadata.Profile["phone"])
+	assert.Equal(t, "Alice", m.Metadata.Profile["name"])
+	assert.Equal(t, "[REDACTED]", m.Metadata.Batch[0]["phone"])
+}
+
 func TestErrors_UnmarshalJSON(t *testing.T) {
 	data := []byte(`{"code":"NOT_FOUND","kind":"not_found","message":"resource not found","trace_id":"t1"}`)
 	var e errors.AppError
@@ -200,6 +233,12 @@ func TestErrors_RBACError(t *testing.T) {
 	assert.Equal(t, "delete", rbac.Action)
 	assert.Equal(t, "documents", rbac.Resource)
 	assert.Equal(t, errors.KindForbidden, rbac.Kind)
+	assert.Contains(t, rbac.Error(), "viewer")
+
+	b, err := json.Marshal(rbac)
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "viewer")
+	assert.NotContains(t, string(b), "documents")
 }
 
 func TestErrors_FieldErrorf(t *testing.T) {
@@ -244,6 +283,17 @@ func TestErrors_PublicError_GenericFallback(t *testing.T) {
 	assert.Equal(t, "an error occurred", errors.PublicError(stdErr))
 }
 
+func TestErrors_PublicError_ListCause(t *testing.T) {
+	e := errors.New(errors.CodeInternal, "batch failed", "batch failed",
+		errors.WithCause(errors.MultiError(errors.NotFound("a"), errors.NotFound("b"))))
+	assert.Equal(t, "batch failed", errors.PublicError(e))
+	assert.Equal(t, errors.LogError, errors.LogLevelOf(e))
+
+	list := errors.MultiError(errors.NotFound("a"), errors.NotFound("b"))
+	assert.Equal(t, "a; b", errors.PublicError(list))
+	assert.Equal(t, errors.LogWarn, errors.LogLevelOf(list))
+}
+
 func TestErrors_PublicError_Nil(t *testing.T) {
 	assert.Empty(t, errors.PublicError(nil))
 }
@@ -267,3 +317,24 @@ func TestErrors_LogLevel(t *testing.T) {
 	assert.Equal(t, errors.LogWarn, errors.ErrInvalidInput.LogLevel())
 	assert.Equal(t, errors.LogError, errors.ErrInternal.LogLevel())
 }
+
+func TestErrors_StackTrace_Format(t *testing.T) {
+	e := errors.New(errors.CodeInternal, "boom", "boom")
+	st := e.StackTrace()
+	require.NotEmpty(t, st)
+	assert.Contains(t, st[0].Function, "TestErrors_StackTrace_Format")
+
+	out := fmt.Sprintf("%+v", errors.Wrap(e, "ctx"))
+	assert.Contains(t, out, "ctx: boom")
+	assert.Contains(t, out, "TestErrors_StackTrace_Format")
+	assert.Equal(t, "boom", fmt.Sprintf("%v", e))
+}
+
+func TestErrors_StackTrace_Disabled(t *testing.T) {
+	errors.SetStackCapture(false)
+	defer errors.SetStackCapture(true)
+
+	e := errors.New(errors.CodeInternal, "boom", "boom")
+	assert.Nil(t, e.StackTrace())
+	assert.Nil(t, errors.StackTraceOf(e))
+}
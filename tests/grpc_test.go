@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/LooneY2K/common-pkg-svc/errors"
+	"github.com/LooneY2K/common-pkg-svc/errors/grpcerr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// dialErrorServer starts an in-process gRPC server whose single unary method
+// returns fail's error, and returns a client connection to it.
+func dialErrorServer(t *testing.T, fail func() error, clientOpts ...grpc.DialOption) *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.UnaryInterceptor(grpcerr.UnaryServerInterceptor()))
+	srv.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "test.Errors",
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Fail",
+			Handler: func(_ any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+				in := new(emptypb.Empty)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				handler := func(context.Context, any) (any, error) { return nil, fail() }
+				return interceptor(ctx, in, &grpc.UnaryServerInfo{FullMethod: "/test.Errors/Fail"}, handler)
+			},
+		}},
+	}, struct{}{})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	opts := append([]grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, clientOpts...)
+	conn, err := grpc.NewClient("passthrough:///bufnet", opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestGRPC_CodeMapping(t *testing.T) {
+	assert.Equal(t, codes.InvalidArgument, grpcerr.CodeOf(errors.KindValidation))
+	assert.Equal(t, codes.NotFound, grpcerr.CodeOf(errors.KindNotFound))
+	assert.Equal(t, codes.ResourceExhausted, grpcerr.CodeOf(errors.KindRateLimit))
+	assert.Equal(t, codes.Internal, grpcerr.CodeOf(errors.Kind("unknown")))
+	assert.Equal(t, errors.KindUnauthorized, grpcerr.KindOf(codes.Unauthenticated))
+	assert.Equal(t, errors.KindInternal, grpcerr.KindOf(codes.DataLoss))
+}
+
+func TestGRPC_StatusRoundTrip(t *testing.T) {
+	ae := errors.New(errors.CodeNotFound, "row missing", "user not found",
+		errors.WithRequestID("req-1"),
+		errors.WithTraceID("trace-1"),
+		errors.WithMetadata(map[string]any{"id": "u1", "password": "x"}),
+	)
+	st := grpcerr.ToStatus(ae)
+	assert.Equal(t, codes.NotFound, st.Code())
+	assert.Equal(t, "user not found", st.Message())
+
+	back := grpcerr.FromStatus(st)
+	require.NotNil(t, back)
+	assert.Equal(t, errors.CodeNotFound, back.Code)
+	assert.Equal(t, errors.KindNotFound, back.Kind)
+	assert.Equal(t, "user not found", back.UserMsg)
+	assert.Equal(t, "req-1", back.RequestID)
+	assert.Equal(t, "trace-1", back.TraceID)
+	assert.Equal(t, "u1", back.Metadata["id"])
+	assert.Equal(t, "[REDACTED]", back.Metadata["password"])
+	assert.Equal(t, http.StatusNotFound, back.HTTPStatus())
+}
+
+func TestGRPC_ToStatus_Foreign(t *testing.T) {
+	assert.Nil(t, grpcerr.ToStatus(nil))
+	assert.Equal(t, codes.DeadlineExceeded, grpcerr.ToStatus(context.DeadlineExceeded).Code())
+
+	st := grpcerr.ToStatus(errors.Wrap(context.Canceled, "db"))
+	assert.Equal(t, codes.Canceled, st.Code())
+
+	raw := status.Error(codes.Aborted, "aborted")
+	assert.Equal(t, codes.Aborted, grpcerr.ToStatus(raw).Code())
+	assert.Equal(t, errors.KindConflict, grpcerr.FromError(raw).Kind)
+}
+
+func TestGRPC_Bufconn(t *testing.T) {
+	reset := time.Now().Add(30 * time.Second)
+	conn := dialErrorServer(t, func() error {
+		return errors.Wrap(errors.NewLimitError(0, reset), "quota check")
+	}, grpc.WithUnaryInterceptor(grpcerr.UnaryClientInterceptor()))
+
+	err := conn.Invoke(context.Background(), "/test.Errors/Fail", &emptypb.Empty{}, &emptypb.Empty{})
+	require.Error(t, err)
+
+	var ae *errors.AppError
+	require.True(t, errors.As(err, &ae))
+	assert.Equal(t, errors.CodeRateLimited, ae.Code)
+	assert.Equal(t, http.StatusTooManyRequests, ae.HTTPStatus())
+	assert.True(t, ae.IsRetryable())
+	assert.True(t, errors.Is(err, errors.ErrRateLimited))
+}
+
+func TestGRPC_Bufconn_PlainClient(t *testing.T) {
+	conn := dialErrorServer(t, func() error {
+		return errors.New(errors.CodeInternal, "pq: connection refused", "")
+	})
+
+	err := conn.Invoke(context.Background(), "/test.Errors/Fail", &emptypb.Empty{}, &emptypb.Empty{})
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, codes.Internal, st.Code())
+	assert.Equal(t, errors.CodeInternal, grpcerr.FromError(err).Code)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/LooneY2K/common-pkg-svc/health"
+	ginhealth "github.com/LooneY2K/common-pkg-svc/health/gin"
+	"github.com/LooneY2K/common-pkg-svc/server"
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func okCheck(name string) health.Checker {
+	return health.CheckFunc(name, func(ctx context.Context) error { return nil })
+}
+
+func failingCheck(name string) health.Checker {
+	return health.CheckFunc(name, func(ctx context.Context) error { return stderrors.New(name + " unreachable") })
+}
+
+func TestHealth_Endpoints_Chi(t *testing.T) {
+	cases := []struct {
+		name    string
+		setup   func(*health.Registry)
+		path    string
+		code    int
+		status  string
+		checked []string
+	}{
+		{"up", func(r *health.Registry) { r.Register(okCheck("db")) }, health.ReadinessPath, http.StatusOK, "up", []string{"db"}},
+		{"degraded", func(r *health.Registry) {
+			r.Register(okCheck("db"))
+			r.Register(failingCheck("cache"), health.NonCritical())
+		}, health.ReadinessPath, http.StatusOK, "degraded", []string{"cache", "db"}},
+		{"down", func(r *health.Registry) {
+			r.Register(failingCheck("db"))
+			r.Register(failingCheck("cache"), health.NonCritical())
+		}, health.ReadinessPath, http.StatusServiceUnavailable, "down", []string{"cache", "db"}},
+		{"liveness ignores readiness checks", func(r *health.Registry) {
+			r.Register(failingCheck("db"))
+			r.RegisterLiveness(okCheck("worker"))
+		}, health.LivenessPath, http.StatusOK, "up", []string{"worker"}},
+		{"healthz runs everything", func(r *health.Registry) {
+			r.Register(okCheck("db"))
+			r.RegisterLiveness(failingCheck("worker"))
+		}, health.HealthPath, http.StatusServiceUnavailable, "down", []string{"db", "worker"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			reg := health.NewRegistry()
+			tc.setup(reg)
+			router := chi.NewRouter()
+			health.Mount(router, reg)
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			assert.Equal(t, tc.code, rec.Code)
+			assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+			m := decodeBody(t, rec)
+			assert.Equal(t, tc.code != http.StatusOK, m["error"])
+			data := m["data"].(map[string]any)
+			assert.Equal(t, tc.status, data["status"])
+			checks := data["checks"].(map[string]any)
+			var names []string
+			for name := range checks {
+				names = append(names, name)
+			}
+			assert.ElementsMatch(t, tc.checked, names)
+		})
+	}
+}
+
+func TestHealth_Report_FailureDetail(t *testing.T) {
+	reg := health.NewRegistry()
+	reg.Register(failingCheck("db"))
+	reg.Register(health.CheckFunc("slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}), health.WithTimeout(50*time.Millisecond))
+
+	start := time.Now()
+	report := reg.Readiness(context.Background())
+
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, health.StatusDown, report.Status)
+	assert.Equal(t, "db unreachable", report.Checks["db"].Error)
+	assert.True(t, report.Checks["db"].Critical)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["slow"].Error)
+}
+
+func TestHealth_Registry_ConcurrentAndCached(t *testing.T) {
+	var calls atomic.Int32
+	slow := func(name string) health.Checker {
+		return health.CheckFunc(name, func(ctx context.Context) error {
+			calls.Add(1)
+			time.Sleep(100 * time.Millisecond)
+			return nil
+		})
+	}
+	reg := health.NewRegistry(health.WithCacheTTL(time.Minute))
+	for i := 0; i < 5; i++ {
+		reg.Register(slow("check" + strconv.Itoa(i)))
+	}
+
+	start := time.Now()
+	report := reg.Readiness(context.Background())
+	assert.Less(t, time.Since(start), 400*time.Millisecond, "checks should run concurrently")
+	assert.Equal(t, health.StatusUp, report.Status)
+
+	reg.Readiness(context.Background())
+	assert.Equal(t, int32(5), calls.Load(), "second run should be served from cache")
+}
+
+func TestHealth_Mount_Gin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	reg := health.NewRegistry()
+	reg.Register(okCheck("db"))
+	engine := gin.New()
+	ginhealth.Mount(engine, reg)
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, health.ReadinessPath, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	reg.SetReady(false)
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, health.ReadinessPath, nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "shutting down", decodeBody(t, rec)["data"].(map[string]any)["reason"])
+}
+
+func TestHealth_ServerShutdownMarksNotReady(t *testing.T) {
+	reg := health.NewRegistry()
+	router := chi.NewRouter()
+	health.Mount(router, reg)
+	port := freePort(t)
+	url := "http://127.0.0.1:" + strconv.Itoa(port) + health.ReadinessPath
+
+	srv := server.New(router, server.ServerConfig{Port: port, ShutdownWait: 5},
+		server.WithSignals(), server.WithHealth(reg), server.WithDrainDelay(300*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- srv.Run(ctx) }()
+
+	resp := waitForServer(t, url)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	cancel()
+	require.Eventually(t, func() bool { return !reg.Ready() }, time.Second, 10*time.Millisecond)
+	resp, err := http.Get(url)
+	require.NoError(t, err, "server keeps serving during the drain delay")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	require.NoError(t, <-done)
+}
@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	apperr "github.com/LooneY2K/common-pkg-svc/errors"
 	log "github.com/LooneY2K/common-pkg-svc/log/custom"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -103,6 +104,39 @@ func TestLogger_WithFields(t *testing.T) {
 	assert.Equal(t, "payment", result["service"])
 }
 
+func TestLogger_WithFields_Escaping(t *testing.T) {
+	buf := &safeBuffer{}
+
+	logger := log.New(
+		log.WithOutput(buf),
+		log.WithMode(log.JSON),
+		log.WithComponent(`api "v2"`),
+	)
+	logger.Info("line one\nsaid \"hi\"", log.String(`weird"key`, "v"))
+
+	result := parseFirstJSONLine(t, buf.Bytes())
+
+	assert.Equal(t, "line one\nsaid \"hi\"", result["msg"])
+	assert.Equal(t, `api "v2"`, result["component"])
+	assert.Equal(t, "v", result[`weird"key`])
+}
+
+func TestLogger_StackField(t *testing.T) {
+	buf := &safeBuffer{}
+
+	logger := log.New(
+		log.WithOutput(buf),
+		log.WithMode(log.JSON),
+	)
+	err := apperr.New(apperr.CodeInternal, "db \"down\"", "")
+	logger.Error("failed", log.Err(err), log.Stack(err))
+
+	result := parseFirstJSONLine(t, buf.Bytes())
+
+	assert.Equal(t, `db "down"`, result["error"])
+	assert.Contains(t, result["stacktrace"], "TestLogger_StackField")
+}
+
 func TestLogger_Concurrency(t *testing.T) {
 	buf := &safeBuffer{}
 
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LooneY2K/common-pkg-svc/errors"
+	"github.com/LooneY2K/common-pkg-svc/request"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type decodeTarget struct {
+	Name    string   `json:"name"`
+	Age     int      `json:"age"`
+	Tags    []string `json:"tags"`
+	Address struct {
+		City string `json:"city"`
+	} `json:"address"`
+}
+
+func jsonRequest(body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestRequest_DecodeJSON(t *testing.T) {
+	var dst decodeTarget
+	appErr := request.DecodeJSON(jsonRequest(`{"name":"Ada","age":36,"tags":["a"],"address":{"city":"London"}}`), &dst, request.Options{})
+
+	require.Nil(t, appErr)
+	assert.Equal(t, "Ada", dst.Name)
+	assert.Equal(t, 36, dst.Age)
+	assert.Equal(t, "London", dst.Address.City)
+}
+
+func TestRequest_DecodeJSON_Errors(t *testing.T) {
+	cases := []struct {
+		name  string
+		body  string
+		opts  request.Options
+		msg   string
+		field string
+	}{
+		{"empty", ``, request.Options{}, "request body must not be empty", ""},
+		{"syntax", `{"name":"Ada",}`, request.Options{}, "request body contains malformed JSON at offset 15", ""},
+		{"truncated", `{"name":"Ada"`, request.Options{}, "request body contains malformed JSON", ""},
+		{"type", `{"name":"Ada","age":"old"}`, request.Options{}, "age: must be an integer, got string (at offset 25)", "age"},
+		{"nested type", `{"address":{"city":1}}`, request.Options{}, "address.city: must be a string, got number (at offset 20)", "address.city"},
+		{"unknown field", `{"name":"Ada","nick":"A"}`, request.Options{DisallowUnknownFields: true}, "nick: is not a known field", "nick"},
+		{"trailing data", `{"name":"Ada"} {"name":"Bob"}`, request.Options{}, "request body must contain a single JSON value", ""},
+		{"too large", `{"name":"` + strings.Repeat("a", 64) + `"}`, request.Options{MaxBytes: 32}, "request body must not exceed 32 bytes", ""},
+		{"not an object", `[1,2]`, request.Options{}, "request body must be a JSON object, got array", ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var dst decodeTarget
+			appErr := request.DecodeJSON(jsonRequest(tc.body), &dst, tc.opts)
+
+			require.NotNil(t, appErr)
+			assert.Equal(t, http.StatusBadRequest, appErr.HTTPStatus())
+			assert.Equal(t, tc.msg, appErr.UserMsg)
+			if tc.field != "" {
+				assert.Contains(t, errors.FieldErrors(appErr), tc.field)
+			}
+		})
+	}
+}
+
+type signupForm struct {
+	Email    string        `form:"email"`
+	Age      int           `json:"age"`
+	Admin    *bool         `form:"admin"`
+	Roles    []string      `form:"role"`
+	Timeout  time.Duration `form:"timeout"`
+	Birthday time.Time     `form:"birthday"`
+	Ignored  string        `form:"-"`
+	Paging   struct {
+		Limit int `form:"limit"`
+	} `form:"page"`
+	Avatar *multipart.FileHeader `form:"avatar"`
+}
+
+func TestRequest_DecodeForm(t *testing.T) {
+	body := "email=ada%40example.com&age=36&admin=true&role=a&role=b&timeout=1m30s&birthday=1815-12-10&Ignored=x&page.limit=5"
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var dst signupForm
+	require.Nil(t, request.DecodeForm(req, &dst, request.Options{}))
+
+	assert.Equal(t, "ada@example.com", dst.Email)
+	assert.Equal(t, 36, dst.Age)
+	require.NotNil(t, dst.Admin)
+	assert.True(t, *dst.Admin)
+	assert.Equal(t, []string{"a", "b"}, dst.Roles)
+	assert.Equal(t, 90*time.Second, dst.Timeout)
+	assert.Equal(t, 1815, dst.Birthday.Year())
+	assert.Empty(t, dst.Ignored)
+	assert.Equal(t, 5, dst.Paging.Limit)
+}
+
+func TestRequest_DecodeForm_Multipart(t *testing.T) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	mw.WriteField("email", "ada@example.com")
+	fw, _ := mw.CreateFormFile("avatar", "ada.png")
+	fw.Write([]byte("png"))
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	var dst signupForm
+	require.Nil(t, request.DecodeForm(req, &dst, request.Options{}))
+
+	assert.Equal(t, "ada@example.com", dst.Email)
+	require.NotNil(t, dst.Avatar)
+	assert.Equal(t, "ada.png", dst.Avatar.Filename)
+	f, err := dst.Avatar.Open()
+	require.NoError(t, err)
+	defer f.Close()
+	content, _ := io.ReadAll(f)
+	assert.Equal(t, "png", string(content))
+}
+
+func TestRequest_DecodeQuery(t *testing.T) {
+	var dst signupForm
+	req := httptest.NewRequest(http.MethodGet, "/?email=ada%40example.com&age=young&admin=maybe&page.limit=5", nil)
+
+	appErr := request.DecodeQuery(req, &dst)
+
+	require.NotNil(t, appErr)
+	assert.Equal(t, http.StatusBadRequest, appErr.HTTPStatus())
+	assert.Equal(t, map[string]string{"age": "must be an integer", "admin": "must be a boolean"}, errors.FieldErrors(appErr))
+	assert.Equal(t, "ada@example.com", dst.Email)
+	assert.Equal(t, 5, dst.Paging.Limit)
+}
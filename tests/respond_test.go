@@ -0,0 +1,823 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	stderrors "errors"
+	"io"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LooneY2K/common-pkg-svc/errors"
+	"github.com/LooneY2K/common-pkg-svc/respond"
+	ginrespond "github.com/LooneY2K/common-pkg-svc/respond/gin"
+	validate "github.com/LooneY2K/common-pkg-svc/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func decodeBody(t *testing.T, rec *httptest.ResponseRecorder) map[string]any {
+	t.Helper()
+
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &m))
+	return m
+}
+
+func TestRespond_ProblemJSON(t *testing.T) {
+	respond.SetProblemTypeBase("https://errors.example.com/")
+	defer respond.SetProblemTypeBase("")
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	rec := httptest.NewRecorder()
+	err := errors.New(errors.CodeNotFound, "sql: no rows", "user not found", errors.WithTraceID("t1"))
+
+	require.Nil(t, respond.ProblemJSON(rec, req, err))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, respond.ProblemContentType, rec.Header().Get("Content-Type"))
+	m := decodeBody(t, rec)
+	assert.Equal(t, "https://errors.example.com/not-found", m["type"])
+	assert.Equal(t, "Not Found", m["title"])
+	assert.Equal(t, float64(404), m["status"])
+	assert.Equal(t, "user not found", m["detail"])
+	assert.Equal(t, "/users/42", m["instance"])
+	assert.Equal(t, "NOT_FOUND", m["code"])
+	assert.Equal(t, "t1", m["trace_id"])
+}
+
+func TestRespond_ProblemJSON_FieldErrors(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/users", nil)
+	rec := httptest.NewRecorder()
+	err := errors.MultiError(
+		errors.FieldErrorf("email", "must be valid format"),
+		errors.FieldErrorf("age", "must be positive"),
+	)
+
+	respond.ProblemJSON(rec, req, err)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	m := decodeBody(t, rec)
+	assert.Equal(t, "about:blank", m["type"])
+	fields, ok := m["errors"].([]any)
+	require.True(t, ok)
+	require.Len(t, fields, 2)
+	assert.Equal(t, "#/age", fields[0].(map[string]any)["pointer"])
+}
+
+func TestRespond_ProblemJSON_FieldErrorCause(t *testing.T) {
+	respond.SetProblemTypeBase("https://errors.example.com/")
+	defer respond.SetProblemTypeBase("")
+
+	req := httptest.NewRequest(http.MethodPost, "/users", nil)
+	rec := httptest.NewRecorder()
+	err := errors.New(errors.CodeValidation, "invalid signup", "invalid signup", errors.WithTraceID("t1"),
+		errors.WithCause(errors.MultiError(
+			errors.FieldErrorf("email", "is required"),
+			errors.FieldErrorf("age", "must be positive"),
+		)))
+
+	respond.ProblemJSON(rec, req, err)
+
+	assert.Equal(t, err.HTTPStatus(), rec.Code)
+	m := decodeBody(t, rec)
+	assert.Equal(t, "https://errors.example.com/validation", m["type"])
+	assert.Equal(t, "VALIDATION", m["code"])
+	assert.Equal(t, "t1", m["trace_id"])
+	assert.Equal(t, "invalid signup", m["detail"])
+	fields, ok := m["errors"].([]any)
+	require.True(t, ok)
+	assert.Len(t, fields, 2)
+}
+
+func TestRespond_ProblemJSON_Validation(t *testing.T) {
+	type address struct {
+		City string `json:"city" validate:"required"`
+	}
+	type item struct {
+		Name string `json:"name" validate:"required"`
+	}
+	type signup struct {
+		Email   string          `json:"email" validate:"required"`
+		Address address         `json:"address"`
+		Items   []item          `json:"items" validate:"dive"`
+		Labels  map[string]item `json:"labels" validate:"dive"`
+	}
+	appErr := validate.ValidateRequestDto(signup{Items: []item{{Name: "a"}, {}}, Labels: map[string]item{"a/b": {}}})
+	require.NotNil(t, appErr)
+	appErr.TraceID = "t1"
+
+	rec := httptest.NewRecorder()
+	respond.ProblemJSON(rec, httptest.NewRequest(http.MethodPost, "/signup", nil), appErr)
+
+	m := decodeBody(t, rec)
+	assert.Equal(t, "t1", m["trace_id"])
+	assert.NotEmpty(t, m["code"])
+	fields, ok := m["errors"].([]any)
+	require.True(t, ok)
+	require.Len(t, fields, 4)
+	assert.Equal(t, map[string]any{"pointer": "#/address/city", "detail": "is required"}, fields[0])
+	assert.Equal(t, map[string]any{"pointer": "#/email", "detail": "is required"}, fields[1])
+	assert.Equal(t, map[string]any{"pointer": "#/items/1/name", "detail": "is required"}, fields[2])
+	assert.Equal(t, map[string]any{"pointer": "#/labels/a~1b/name", "detail": "is required"}, fields[3])
+}
+
+func TestRespond_ErrorFor_Negotiation(t *testing.T) {
+	err := errors.New(errors.CodeForbidden, "role viewer", "forbidden")
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/problem+json, application/json;q=0.5")
+	rec := httptest.NewRecorder()
+	respond.ErrorFor(rec, req, err)
+	assert.Equal(t, respond.ProblemContentType, rec.Header().Get("Content-Type"))
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/problem+json;q=0, application/json")
+	rec = httptest.NewRecorder()
+	respond.ErrorFor(rec, req.WithContext(respond.WithErrorFormat(req.Context(), respond.FormatProblem)), err)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"), "q=0 refuses problem+json")
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	rec = httptest.NewRecorder()
+	respond.ErrorFor(rec, req, err)
+	assert.Equal(t, true, decodeBody(t, rec)["error"])
+
+	handler := respond.UseErrorFormat(respond.FormatProblem)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		respond.ErrorFor(rw, r, err)
+	}))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, respond.ProblemContentType, rec.Header().Get("Content-Type"))
+}
+
+func TestRespond_OK_HeadersBeforeStatus(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	require.Nil(t, respond.OK(rec, map[string]any{"id": 1}))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, strconv.Itoa(rec.Body.Len()), rec.Header().Get("Content-Length"))
+	m := decodeBody(t, rec)
+	assert.Equal(t, float64(200), m["status"])
+	assert.Equal(t, false, m["error"])
+}
+
+func TestRespond_Error_Envelope(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	respond.Error(rec, errors.New(errors.CodeNotFound, "sql: no rows", "user not found"))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	m := decodeBody(t, rec)
+	assert.Equal(t, true, m["error"])
+	assert.Equal(t, "user not found", m["message"])
+}
+
+func TestRespond_Writer_HeadersCookiesETag(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	appErr := respond.NewWriter(rec).
+		Header("X-Request-Id", "req-1").
+		Cookie(&http.Cookie{Name: "session", Value: "abc"}).
+		ETag("v1").
+		JSON(http.StatusOK, map[string]string{"a": "b"})
+
+	require.Nil(t, appErr)
+	assert.Equal(t, "req-1", rec.Header().Get("X-Request-Id"))
+	assert.Equal(t, `"v1"`, rec.Header().Get("ETag"))
+	require.Len(t, rec.Result().Cookies(), 1)
+	assert.Equal(t, "abc", rec.Result().Cookies()[0].Value)
+}
+
+func TestRespond_Writer_NotModified(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", `W/"other", "v1"`)
+	rec := httptest.NewRecorder()
+
+	respond.NewWriter(rec).Request(req).ETag(`"v1"`).JSON(http.StatusOK, "body")
+
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Zero(t, rec.Body.Len())
+}
+
+func TestRespond_Writer_MarshalFailure(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	appErr := respond.OK(rec, map[string]any{"ch": make(chan int)})
+
+	require.NotNil(t, appErr)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	m := decodeBody(t, rec)
+	assert.Equal(t, true, m["error"])
+	assert.Equal(t, float64(500), m["status"])
+}
+
+func TestRespond_Negotiate_Codecs(t *testing.T) {
+	cases := []struct {
+		accept      string
+		contentType string
+	}{
+		{"", "application/json"},
+		{"*/*", "application/json"},
+		{"application/xml", "application/xml"},
+		{"text/xml", "application/xml"},
+		{"application/x-msgpack", "application/msgpack"},
+		{"application/cbor", "application/cbor"},
+		{"text/plain", "text/plain; charset=utf-8"},
+		{"text/html;q=0.9, application/cbor;q=0.5, application/json;q=0.8", "application/json"},
+		{"application/*;q=0.5, text/plain", "text/plain; charset=utf-8"},
+		{"application/json;q=0, */*;q=0.1", "application/xml"},
+		{"text/*;q=0, text/plain", "text/plain; charset=utf-8"},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", c.accept)
+		rec := httptest.NewRecorder()
+
+		respond.NewWriter(rec).Negotiate(req).Respond(respond.Response{Status: http.StatusOK, Data: "hi"})
+
+		assert.Equal(t, http.StatusOK, rec.Code, "accept %q", c.accept)
+		assert.Equal(t, c.contentType, rec.Header().Get("Content-Type"), "accept %q", c.accept)
+	}
+}
+
+func TestRespond_Negotiate_NotAcceptable(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "image/png, application/json;q=0")
+	rec := httptest.NewRecorder()
+
+	respond.NewWriter(rec).Negotiate(req).Respond(respond.Response{Status: http.StatusOK, Data: "hi"})
+
+	assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, float64(406), decodeBody(t, rec)["status"])
+}
+
+func TestRespond_Error_Envelope_OuterStatus(t *testing.T) {
+	rec := httptest.NewRecorder()
+	err := errors.New(errors.CodeInternal, "batch failed", "batch failed",
+		errors.WithCause(errors.MultiError(errors.NotFound("a"), errors.NotFound("b"))))
+
+	respond.Errors(rec, err)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	m := decodeBody(t, rec)
+	assert.Equal(t, "batch failed", m["message"])
+	assert.Len(t, m["errors"], 2)
+
+	rec = httptest.NewRecorder()
+	respond.Errors(rec, errors.NotFound("a"), errors.InternalServerError("b"))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Len(t, decodeBody(t, rec)["errors"], 2)
+}
+
+func TestRespond_Negotiate_EnvelopeShape(t *testing.T) {
+	envelope := respond.Response{Status: http.StatusOK, Data: map[string]any{"id": 7, "name": "foo"}}
+	encode := func(accept string) []byte {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		respond.NewWriter(rec).Negotiate(req).Respond(envelope)
+		return rec.Body.Bytes()
+	}
+
+	for name, h := range map[string]codec.Handle{"application/msgpack": &codec.MsgpackHandle{}, "application/cbor": &codec.CborHandle{}} {
+		var m map[string]any
+		require.NoError(t, codec.NewDecoderBytes(encode(name), h).Decode(&m), name)
+		assert.EqualValues(t, 200, m["status"], name)
+		assert.Equal(t, false, m["error"], name)
+		data, ok := m["data"].(map[any]any)
+		require.True(t, ok, name)
+		assert.EqualValues(t, 7, data["id"], name)
+	}
+
+	var x struct {
+		Status int    `xml:"status"`
+		Error  bool   `xml:"error"`
+		Name   string `xml:"data>name"`
+	}
+	require.NoError(t, xml.Unmarshal(encode("application/xml"), &x))
+	assert.Equal(t, 200, x.Status)
+	assert.False(t, x.Error)
+	assert.Equal(t, "foo", x.Name)
+}
+
+func TestRespond_ParsePage(t *testing.T) {
+	opts := respond.PageOptions{DefaultLimit: 10, MaxLimit: 50}
+
+	page, appErr := respond.ParsePage(httptest.NewRequest(http.MethodGet, "/items", nil), opts)
+	require.Nil(t, appErr)
+	assert.Equal(t, 10, page.Limit)
+	assert.Equal(t, 0, page.Offset)
+
+	page, appErr = respond.ParsePage(httptest.NewRequest(http.MethodGet, "/items?limit=25&offset=50", nil), opts)
+	require.Nil(t, appErr)
+	assert.Equal(t, 25, page.Limit)
+	assert.Equal(t, 50, page.Offset)
+
+	page, appErr = respond.ParsePage(httptest.NewRequest(http.MethodGet, "/items?cursor=abc", nil), opts)
+	require.Nil(t, appErr)
+	assert.Equal(t, "abc", page.Cursor)
+}
+
+func TestRespond_ParsePage_Invalid(t *testing.T) {
+	opts := respond.PageOptions{MaxLimit: 50}
+
+	_, appErr := respond.ParsePage(httptest.NewRequest(http.MethodGet, "/items?limit=500&offset=-1", nil), opts)
+	require.NotNil(t, appErr)
+	assert.Equal(t, http.StatusBadRequest, appErr.HTTPStatus())
+	fields := errors.FieldErrors(appErr)
+	assert.Contains(t, fields, "limit")
+	assert.Contains(t, fields, "offset")
+
+	_, appErr = respond.ParsePage(httptest.NewRequest(http.MethodGet, "/items?cursor=x&offset=5", nil), opts)
+	require.NotNil(t, appErr)
+	assert.Contains(t, errors.FieldErrors(appErr), "offset")
+}
+
+func TestRespond_Page_Offset(t *testing.T) {
+	page, appErr := respond.ParsePage(httptest.NewRequest(http.MethodGet, "/items?limit=10&offset=10&sort=name", nil), respond.PageOptions{})
+	require.Nil(t, appErr)
+	rec := httptest.NewRecorder()
+
+	require.Nil(t, respond.Page(rec, []int{1, 2, 3}, respond.OffsetPage(page, 35)))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	link := rec.Header().Get("Link")
+	assert.Contains(t, link, `</items?limit=10&offset=0&sort=name>; rel="first"`)
+	assert.Contains(t, link, `</items?limit=10&offset=0&sort=name>; rel="prev"`)
+	assert.Contains(t, link, `</items?limit=10&offset=20&sort=name>; rel="next"`)
+	assert.Contains(t, link, `</items?limit=10&offset=30&sort=name>; rel="last"`)
+
+	m := decodeBody(t, rec)
+	pagination, ok := m["pagination"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, float64(35), pagination["total"])
+	assert.Equal(t, float64(10), pagination["offset"])
+	assert.Equal(t, true, pagination["has_more"])
+}
+
+func TestRespond_Page_Cursor(t *testing.T) {
+	next, err := respond.EncodeCursor(map[string]int{"id": 42})
+	require.NoError(t, err)
+	var decoded map[string]int
+	require.Nil(t, respond.DecodeCursor(next, &decoded))
+	assert.Equal(t, 42, decoded["id"])
+	assert.NotNil(t, respond.DecodeCursor("!!", &decoded))
+
+	page, appErr := respond.ParsePage(httptest.NewRequest(http.MethodGet, "/items?cursor=prev", nil), respond.PageOptions{})
+	require.Nil(t, appErr)
+	rec := httptest.NewRecorder()
+
+	respond.Page(rec, []string{"a"}, respond.CursorPage(page, next, ""))
+
+	assert.Equal(t, `</items?cursor=`+next+`&limit=20>; rel="next"`, rec.Header().Get("Link"))
+	pagination := decodeBody(t, rec)["pagination"].(map[string]any)
+	assert.Equal(t, next, pagination["next_cursor"])
+	assert.NotContains(t, pagination, "total")
+	assert.NotContains(t, pagination, "offset")
+}
+
+func TestRespond_NDJSON_Chi(t *testing.T) {
+	router := chi.NewRouter()
+	router.Get("/export", func(rw http.ResponseWriter, r *http.Request) {
+		respond.NDJSON(rw, r, slices.Values([]map[string]int{{"n": 1}, {"n": 2}, {"n": 3}}))
+	})
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/export")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, respond.NDJSONContentType, resp.Header.Get("Content-Type"))
+	dec := json.NewDecoder(resp.Body)
+	for want := 1; want <= 3; want++ {
+		var line map[string]int
+		require.NoError(t, dec.Decode(&line))
+		assert.Equal(t, want, line["n"])
+	}
+}
+
+func TestRespond_NDJSON_StopsOnDisconnect(t *testing.T) {
+	produced := make(chan int, 1000)
+	seq := func(yield func(int) bool) {
+		for i := 0; ; i++ {
+			produced <- i
+			if !yield(i) {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	result := make(chan error, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		result <- respond.NDJSON(rw, r, iter.Seq[int](seq))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	bufio.NewReader(resp.Body).ReadString('\n')
+	cancel()
+	resp.Body.Close()
+
+	select {
+	case err := <-result:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("NDJSON did not stop after client disconnect")
+	}
+}
+
+func TestRespond_SSE_Gin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/events", func(c *gin.Context) {
+		start, _ := strconv.Atoi(c.GetHeader("Last-Event-ID"))
+		seq := func(yield func(int) bool) {
+			for i := start + 1; i <= start+2; i++ {
+				if !yield(i) {
+					return
+				}
+			}
+		}
+		respond.StreamSSE(c.Writer, c.Request, respond.SSEOptions{Retry: 3 * time.Second}, iter.Seq[int](seq), func(i int) respond.Event {
+			return respond.Event{ID: strconv.Itoa(i), Event: "tick", Data: map[string]int{"i": i}}
+		})
+	})
+	srv := httptest.NewServer(engine)
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/events", nil)
+	req.Header.Set("Last-Event-ID", "5")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "retry: 3000\n\n"+
+		"id: 6\nevent: tick\ndata: {\"i\":6}\n\n"+
+		"id: 7\nevent: tick\ndata: {\"i\":7}\n\n", string(body))
+}
+
+func TestRespond_SSE_LineTerminators(t *testing.T) {
+	rec := httptest.NewRecorder()
+	sse, err := respond.NewSSE(rec, httptest.NewRequest(http.MethodGet, "/", nil), respond.SSEOptions{})
+	require.NoError(t, err)
+	require.NoError(t, sse.Send(respond.Event{ID: "1\r", Data: "a\rb\r\nc\nd"}))
+	sse.Close()
+
+	assert.Equal(t, "id: 1\ndata: a\ndata: b\ndata: c\ndata: d\n\n", rec.Body.String())
+}
+
+func TestRespond_SSE_Heartbeat(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		sse, err := respond.NewSSE(rw, r, respond.SSEOptions{Heartbeat: 10 * time.Millisecond})
+		require.NoError(t, err)
+		defer sse.Close()
+		sse.Send(respond.Event{Data: "line one\nline two"})
+		<-sse.Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+	var lines []string
+	for len(lines) < 5 {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		lines = append(lines, line)
+	}
+	assert.Equal(t, []string{"data: line one\n", "data: line two\n", "\n", ": heartbeat\n", "\n"}, lines)
+}
+
+func TestRespond_Gin_MatchesChi(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	notFound := errors.New(errors.CodeNotFound, "sql: no rows", "user not found")
+
+	router := chi.NewRouter()
+	router.Get("/ok", func(rw http.ResponseWriter, r *http.Request) { respond.OK(rw, map[string]int{"id": 1}) })
+	router.Get("/err", func(rw http.ResponseWriter, r *http.Request) { respond.ErrorFor(rw, r, notFound) })
+
+	engine := gin.New()
+	engine.GET("/ok", func(c *gin.Context) { ginrespond.OK(c, map[string]int{"id": 1}) })
+	engine.GET("/err", func(c *gin.Context) { ginrespond.Error(c, notFound) })
+
+	for _, path := range []string{"/ok", "/err"} {
+		chiRec, ginRec := httptest.NewRecorder(), httptest.NewRecorder()
+		router.ServeHTTP(chiRec, httptest.NewRequest(http.MethodGet, path, nil))
+		engine.ServeHTTP(ginRec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		assert.Equal(t, chiRec.Code, ginRec.Code, path)
+		assert.Equal(t, chiRec.Header().Get("Content-Type"), ginRec.Header().Get("Content-Type"), path)
+		assert.Equal(t, chiRec.Body.String(), ginRec.Body.String(), path)
+	}
+}
+
+func TestRespond_Gin_AbortWithError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	reached := false
+	engine.GET("/", func(c *gin.Context) {
+		ginrespond.AbortWithError(c, errors.New(errors.CodeForbidden, "role viewer", "not allowed"))
+	}, func(c *gin.Context) { reached = true })
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.False(t, reached)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "not allowed", decodeBody(t, rec)["message"])
+}
+
+func TestRespond_Gin_ErrorHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(ginrespond.ErrorHandler())
+	engine.GET("/one", func(c *gin.Context) {
+		c.Error(errors.New(errors.CodeConflict, "duplicate key", "email already taken"))
+	})
+	engine.GET("/many", func(c *gin.Context) {
+		c.Error(errors.FieldErrorf("name", "is required"))
+		c.Error(errors.New(errors.CodeInternal, "db down", ""))
+	})
+	engine.GET("/written", func(c *gin.Context) {
+		c.Error(errors.New(errors.CodeInternal, "ignored", ""))
+		ginrespond.OK(c, "done")
+	})
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/one", nil))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "email already taken", decodeBody(t, rec)["message"])
+
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/many", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Len(t, decodeBody(t, rec)["errors"], 2)
+
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/written", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "done", decodeBody(t, rec)["data"])
+}
+
+func TestRespond_Gin_ProblemFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(ginrespond.UseErrorFormat(respond.FormatProblem))
+	engine.GET("/", func(c *gin.Context) {
+		ginrespond.Error(c, errors.New(errors.CodeNotFound, "sql: no rows", "user not found"))
+	})
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, respond.ProblemContentType, rec.Header().Get("Content-Type"))
+	assert.Equal(t, "user not found", decodeBody(t, rec)["detail"])
+}
+
+func observedLogger() (*zap.SugaredLogger, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	return zap.New(core).Sugar(), logs
+}
+
+func TestRespond_HandleErrors_Panic(t *testing.T) {
+	lgr, logs := observedLogger()
+	router := chi.NewRouter()
+	router.Use(respond.HandleErrors(lgr))
+	router.Get("/", func(rw http.ResponseWriter, r *http.Request) { panic("boom") })
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	m := decodeBody(t, rec)
+	assert.Equal(t, "Internal Server Error", m["message"])
+	assert.Equal(t, rec.Header().Get(respond.RequestIDHeader), m["request_id"])
+	assert.NotEmpty(t, m["request_id"])
+
+	require.Equal(t, 1, logs.Len())
+	entry := logs.All()[0]
+	assert.Equal(t, zapcore.ErrorLevel, entry.Level)
+	assert.Equal(t, "panic: boom", entry.ContextMap()["error"])
+	assert.Contains(t, entry.ContextMap()["error.stack_trace"], "TestRespond_HandleErrors_Panic")
+}
+
+func TestRespond_HandleErrors_AttachedErrors(t *testing.T) {
+	type signup struct {
+		Email string `validate:"required,email"`
+		Name  string `validate:"required"`
+	}
+	lgr, logs := observedLogger()
+	router := chi.NewRouter()
+	router.Use(respond.HandleErrors(lgr))
+	router.Post("/signup", func(rw http.ResponseWriter, r *http.Request) {
+		respond.AttachError(r, validator.New().Struct(signup{Email: "nope"}))
+	})
+	router.Get("/db", func(rw http.ResponseWriter, r *http.Request) {
+		respond.AttachError(r, stderrors.New("dial tcp 10.0.0.1:5432: connection refused"))
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/signup", nil)
+	req.Header.Set(respond.RequestIDHeader, "req-123")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	m := decodeBody(t, rec)
+	assert.Equal(t, "req-123", m["request_id"])
+	assert.Len(t, m["errors"], 2)
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, zapcore.WarnLevel, logs.All()[0].Level)
+	assert.Equal(t, "req-123", logs.All()[0].ContextMap()["request_id"])
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/db", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "10.0.0.1")
+	assert.Equal(t, zapcore.ErrorLevel, logs.All()[1].Level)
+}
+
+func TestRespond_HandleErrors_Gin(t *testing.T) {
+	type signup struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	gin.SetMode(gin.TestMode)
+	lgr, logs := observedLogger()
+	engine := gin.New()
+	engine.Use(ginrespond.HandleErrors(lgr))
+	engine.POST("/signup", func(c *gin.Context) {
+		var body signup
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.Error(err)
+			return
+		}
+		ginrespond.Created(c, body)
+	})
+	engine.GET("/panic", func(c *gin.Context) { panic(stderrors.New("nil map")) })
+	engine.GET("/rendered", func(c *gin.Context) {
+		ginrespond.Error(c, errors.New(errors.CodeNotFound, "sql: no rows", "user not found"))
+	})
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(`{"email":"nope"}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get(respond.RequestIDHeader))
+	m := decodeBody(t, rec)
+	assert.Len(t, m["errors"], 1)
+
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "Internal Server Error", decodeBody(t, rec)["message"])
+
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/rendered", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	levels := make([]zapcore.Level, 0, logs.Len())
+	for _, entry := range logs.All() {
+		levels = append(levels, entry.Level)
+	}
+	assert.Equal(t, []zapcore.Level{zapcore.WarnLevel, zapcore.ErrorLevel, zapcore.WarnLevel}, levels)
+}
+
+type createUserReq struct {
+	Name  string `json:"name" validate:"required"`
+	Email string `json:"email" validate:"required,email"`
+}
+
+type createUserResp struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func (createUserResp) StatusCode() int { return http.StatusCreated }
+
+func createUser(ctx context.Context, req createUserReq) (createUserResp, error) {
+	if req.Email == "taken@example.com" {
+		return createUserResp{}, errors.New(errors.CodeConflict, "duplicate key", "email already taken")
+	}
+	return createUserResp{ID: 7, Name: req.Name}, nil
+}
+
+func TestRespond_Endpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := chi.NewRouter()
+	router.Method(http.MethodPost, "/users", respond.Endpoint(createUser))
+	engine := gin.New()
+	engine.POST("/users", ginrespond.Handle(respond.Endpoint(createUser)))
+
+	cases := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"created", `{"name":"Ada","email":"ada@example.com"}`, http.StatusCreated},
+		{"invalid json", `{"name":`, http.StatusBadRequest},
+		{"validation", `{"name":"Ada"}`, http.StatusBadRequest},
+		{"handler error", `{"name":"Ada","email":"taken@example.com"}`, http.StatusConflict},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			chiRec, ginRec := httptest.NewRecorder(), httptest.NewRecorder()
+			router.ServeHTTP(chiRec, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tc.body)))
+			engine.ServeHTTP(ginRec, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tc.body)))
+
+			assert.Equal(t, tc.status, chiRec.Code)
+			assert.Equal(t, chiRec.Code, ginRec.Code)
+			chiBody, ginBody := decodeBody(t, chiRec), decodeBody(t, ginRec)
+			assert.Equal(t, chiBody["message"], ginBody["message"])
+			assert.Equal(t, chiBody["data"], ginBody["data"])
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"Ada","email":"ada@example.com"}`)))
+	assert.Equal(t, map[string]any{"id": float64(7), "name": "Ada"}, decodeBody(t, rec)["data"])
+}
+
+func TestRespond_Handler_AlreadyWritten(t *testing.T) {
+	h := respond.Handler(func(rw http.ResponseWriter, r *http.Request) error {
+		respond.OK(rw, "done")
+		return errors.InternalServerError("audit log failed")
+	})
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "done", decodeBody(t, rec)["data"], "the body holds a single envelope")
+
+	rec = httptest.NewRecorder()
+	respond.Handler(func(rw http.ResponseWriter, r *http.Request) error {
+		return errors.NotFound("user not found")
+	}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestRespond_Handler_WithMiddleware(t *testing.T) {
+	lgr, logs := observedLogger()
+	router := chi.NewRouter()
+	router.Use(respond.HandleErrors(lgr))
+	router.Method(http.MethodGet, "/", respond.Handler(func(rw http.ResponseWriter, r *http.Request) error {
+		return errors.New(errors.CodeNotFound, "sql: no rows", "user not found")
+	}))
+	router.Method(http.MethodGet, "/ping", respond.Endpoint(func(ctx context.Context, _ struct{}) (string, error) {
+		return "pong", nil
+	}))
+	router.Method(http.MethodGet, "/partial", respond.Handler(func(rw http.ResponseWriter, r *http.Request) error {
+		rw.Write([]byte("partial"))
+		return errors.InternalServerError("stream broke")
+	}))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "user not found", decodeBody(t, rec)["message"])
+	assert.Equal(t, 1, logs.Len())
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ping", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "pong", decodeBody(t, rec)["data"])
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/partial", nil))
+	assert.Equal(t, "partial", rec.Body.String(), "a written response is left alone")
+	require.Equal(t, 2, logs.Len())
+	assert.Equal(t, zapcore.ErrorLevel, logs.All()[1].Level)
+}
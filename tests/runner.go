@@ -13,15 +13,20 @@ import (
 )
 
 var testGroups = map[string][]string{
-	"log":       {"fields", "level", "mode", "logger", "optionsLog", "pretty", "jsonLog", "benchmark", "allLog"},
+	"log":       {"fields", "level", "mode", "logger", "optionsLog", "pretty", "jsonLog", "stackLog", "benchmark", "allLog"},
 	"config":    {"loadConfig", "fromMap", "get", "getString", "getInt", "getInt64", "getBool", "getFloat64", "getDuration", "getOrDefault", "getStringOrDefault", "getIntOrDefault", "getBoolOrDefault", "has", "unmarshalKey", "set", "allConfig"},
 	"converter": {"toString", "toInt", "toInt64", "toBool", "toDuration", "allConverter"},
 	"json":      {"loadJson", "unmarshal", "unmarshalInto", "notFound", "invalidJSON", "allJson"},
-	"errors":    {"new", "sentinels", "wrap", "rootCause", "fromError", "httpStatus", "optionsErrors", "jsonErrors", "specialized", "multiError", "publicError", "helpers", "allErrors"},
+	"respond":   {"envelope", "writer", "problem", "negotiate", "page", "stream", "ginRespond", "middleware", "handler", "allRespond"},
+	"request":   {"decodeJSON", "decodeForm", "decodeQuery", "allRequest"},
+	"server":    {"run", "routers", "group", "hooks", "tls", "addr", "restart", "protocols", "allServer"},
+	"health":    {"endpoints", "checks", "readiness", "allHealth"},
+	"grpc":      {"codeMapping", "statusRoundTrip", "bufconn", "allGrpc"},
+	"errors":    {"new", "sentinels", "wrap", "rootCause", "fromError", "httpStatus", "optionsErrors", "jsonErrors", "specialized", "multiError", "publicError", "helpers", "stack", "allErrors"},
 }
 
 var testPatterns = map[string]string{
-	"allLog":             "TestLogger_WithFields|TestLogger_LevelFiltering|TestLogger_Concurrency|TestLogger_Info_Output",
+	"allLog":             "TestLogger_WithFields|TestLogger_LevelFiltering|TestLogger_Concurrency|TestLogger_Info_Output|TestLogger_StackField",
 	"fields":             "TestLogger_WithFields",
 	"level":              "TestLogger_LevelFiltering",
 	"mode":               "TestLogger_Info_Output|TestLogger_WithFields",
@@ -29,6 +34,7 @@ var testPatterns = map[string]string{
 	"optionsLog":         "TestLogger",
 	"pretty":             "TestLogger_LevelFiltering|TestLogger_Concurrency",
 	"jsonLog":            "TestLogger_Info_Output|TestLogger_WithFields",
+	"stackLog":           "TestLogger_StackField",
 	"benchmark":          "BenchmarkLogger_Info",
 	"allConfig":          "TestConfig_Load|TestConfig_FromMap|TestConfig_Get|TestConfig_GetString|TestConfig_GetInt|TestConfig_GetInt64|TestConfig_GetBool|TestConfig_GetFloat64|TestConfig_GetDuration|TestConfig_GetOrDefault|TestConfig_GetStringOrDefault|TestConfig_GetIntOrDefault|TestConfig_GetBoolOrDefault|TestConfig_Has|TestConfig_UnmarshalKey|TestConfig_Set",
 	"loadConfig":         "TestConfig_Load",
@@ -72,6 +78,38 @@ var testPatterns = map[string]string{
 	"multiError":         "TestErrors_MultiError",
 	"publicError":        "TestErrors_PublicError",
 	"helpers":            "TestErrors_IsRetryable|TestErrors_IsTimeoutErr|TestErrors_LogLevel",
+	"stack":              "TestErrors_StackTrace",
+	"allGrpc":            "TestGRPC_",
+	"allRespond":         "TestRespond_",
+	"decodeJSON":         "TestRequest_DecodeJSON",
+	"decodeForm":         "TestRequest_DecodeForm",
+	"decodeQuery":        "TestRequest_DecodeQuery",
+	"allRequest":         "TestRequest_",
+	"run":                "TestServer_Run",
+	"routers":            "TestServer_New_Routers",
+	"group":              "TestServer_Group",
+	"hooks":              "TestServer_Hooks",
+	"tls":                "TestServer_TLS",
+	"addr":               "TestServer_Addr",
+	"restart":            "TestServer_Restart",
+	"protocols":          "TestServer_Protocols",
+	"allServer":          "TestServer_",
+	"endpoints":          "TestHealth_Endpoints|TestHealth_Mount",
+	"checks":             "TestHealth_Report|TestHealth_Registry",
+	"readiness":          "TestHealth_Mount_Gin|TestHealth_ServerShutdownMarksNotReady",
+	"allHealth":          "TestHealth_",
+	"problem":            "TestRespond_ProblemJSON|TestRespond_ErrorFor",
+	"envelope":           "TestRespond_OK|TestRespond_Error_Envelope",
+	"writer":             "TestRespond_Writer",
+	"negotiate":          "TestRespond_Negotiate",
+	"page":               "TestRespond_ParsePage|TestRespond_Page",
+	"stream":             "TestRespond_NDJSON|TestRespond_SSE",
+	"ginRespond":         "TestRespond_Gin_",
+	"middleware":         "TestRespond_HandleErrors",
+	"handler":            "TestRespond_Endpoint|TestRespond_Handler",
+	"codeMapping":        "TestGRPC_CodeMapping",
+	"statusRoundTrip":    "TestGRPC_StatusRoundTrip|TestGRPC_ToStatus_Foreign",
+	"bufconn":            "TestGRPC_Bufconn",
 }
 
 func main() {
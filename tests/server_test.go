@@ -0,0 +1,792 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	stderrors "errors"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/LooneY2K/common-pkg-svc/health"
+	"github.com/LooneY2K/common-pkg-svc/server"
+	ginserver "github.com/LooneY2K/common-pkg-svc/server/gin"
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/chi/v5"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func freePort(t *testing.T) int {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func waitForServer(t *testing.T, url string) *http.Response {
+	t.Helper()
+
+	var lastErr error
+	for i := 0; i < 100; i++ {
+		resp, err := http.Get(url)
+		if err == nil {
+			return resp
+		}
+		lastErr = err
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("server at %s never became reachable: %v", url, lastErr)
+	return nil
+}
+
+func TestServer_Run_ContextCancel(t *testing.T) {
+	port := freePort(t)
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) { rw.Write([]byte("ok")) })
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- server.Run(ctx, nil, handler, server.ServerConfig{Port: port, ShutdownWait: 5}) }()
+
+	resp := waitForServer(t, "http://127.0.0.1:"+strconv.Itoa(port))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after context cancellation")
+	}
+}
+
+func TestServer_Run_ListenError(t *testing.T) {
+	ln, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	err = server.Run(context.Background(), nil, http.NotFoundHandler(), server.ServerConfig{Port: ln.Addr().(*net.TCPAddr).Port})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "listen")
+}
+
+func TestServer_Run_ShutdownTimeout(t *testing.T) {
+	port := freePort(t)
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(3 * time.Second)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- server.Run(ctx, nil, handler, server.ServerConfig{Port: port, ShutdownWait: 1}) }()
+	addr := "127.0.0.1:" + strconv.Itoa(port)
+	for i := 0; i < 100; i++ {
+		if conn, err := net.Dial("tcp", addr); err == nil {
+			conn.Close()
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	go func() {
+		if resp, err := http.Get("http://" + addr); err == nil {
+			resp.Body.Close()
+		}
+	}()
+
+	<-started
+	cancel()
+	err := <-done
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestServer_Run_Signal(t *testing.T) {
+	port := freePort(t)
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Run(context.Background(), nil, http.NotFoundHandler(), server.ServerConfig{Port: port, ShutdownWait: 5})
+	}()
+	resp := waitForServer(t, "http://127.0.0.1:"+strconv.Itoa(port))
+	resp.Body.Close()
+
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after SIGTERM")
+	}
+}
+
+func TestServer_New_Routers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/who", func(rw http.ResponseWriter, r *http.Request) { rw.Write([]byte("mux")) })
+	engine := gin.New()
+	engine.GET("/who", func(c *gin.Context) { c.String(http.StatusOK, "gin") })
+	router := chi.NewRouter()
+	router.Get("/who", func(rw http.ResponseWriter, r *http.Request) { rw.Write([]byte("chi")) })
+
+	cases := map[string]func(server.ServerConfig) *server.Server{
+		"mux": func(cfg server.ServerConfig) *server.Server { return server.New(mux, cfg, server.WithSignals()) },
+		"chi": func(cfg server.ServerConfig) *server.Server { return server.New(router, cfg, server.WithSignals()) },
+		"gin": func(cfg server.ServerConfig) *server.Server { return ginserver.New(engine, cfg, server.WithSignals()) },
+	}
+	for name, newServer := range cases {
+		t.Run(name, func(t *testing.T) {
+			port := freePort(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() { done <- newServer(server.ServerConfig{Port: port, ShutdownWait: 5}).Run(ctx) }()
+
+			resp := waitForServer(t, "http://127.0.0.1:"+strconv.Itoa(port)+"/who")
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			assert.Equal(t, name, string(body))
+
+			cancel()
+			assert.NoError(t, <-done)
+		})
+	}
+}
+
+func TestServer_Group_ReverseShutdown(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) server.Runner {
+		return func(ctx context.Context) error {
+			<-ctx.Done()
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			return ctx.Err()
+		}
+	}
+	apiPort, adminPort := freePort(t), freePort(t)
+	g := server.NewGroup(5*time.Second, server.WithSignals()).
+		Go("first", record("first")).
+		Add("api", server.New(http.NotFoundHandler(), server.ServerConfig{Port: apiPort})).
+		Add("admin", server.New(http.NotFoundHandler(), server.ServerConfig{Port: adminPort})).
+		Go("last", record("last"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- g.Run(ctx) }()
+	for _, port := range []int{apiPort, adminPort} {
+		resp := waitForServer(t, "http://127.0.0.1:"+strconv.Itoa(port))
+		resp.Body.Close()
+	}
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.Equal(t, []string{"last", "first"}, order)
+}
+
+func TestServer_Group_FailureCancelsAll(t *testing.T) {
+	port := freePort(t)
+	stopped := make(chan struct{})
+	g := server.NewGroup(5*time.Second, server.WithSignals()).
+		Add("api", server.New(http.NotFoundHandler(), server.ServerConfig{Port: port})).
+		Go("worker", func(ctx context.Context) error {
+			<-ctx.Done()
+			close(stopped)
+			return nil
+		}).
+		Go("consumer", func(ctx context.Context) error {
+			return stderrors.New("broker unreachable")
+		})
+
+	err := g.Run(context.Background())
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "consumer: broker unreachable")
+	<-stopped
+	_, dialErr := net.Dial("tcp", "127.0.0.1:"+strconv.Itoa(port))
+	assert.Error(t, dialErr)
+}
+
+func TestServer_Group_StartFailure(t *testing.T) {
+	ln, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	defer ln.Close()
+	ran := false
+
+	g := server.NewGroup(5*time.Second, server.WithSignals()).
+		Go("worker", func(ctx context.Context) error {
+			<-ctx.Done()
+			ran = true
+			return nil
+		}).
+		Add("api", server.New(http.NotFoundHandler(), server.ServerConfig{Port: ln.Addr().(*net.TCPAddr).Port}))
+
+	err = g.Run(context.Background())
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "api: server: listen")
+	assert.True(t, ran)
+}
+
+func TestServer_Group_NoShutdownBudget(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		g := server.NewGroup(0, server.WithSignals()).
+			Go("worker", func(ctx context.Context) error {
+				<-ctx.Done()
+				return nil
+			})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.NoError(t, g.Run(ctx), "a runner that stops is not reported with no budget")
+	}
+}
+
+func TestServer_Group_MemberOptions(t *testing.T) {
+	reg := health.NewRegistry()
+	var events []string
+	hook := func(name string) server.Hook {
+		return server.Hook{Name: name, Fn: func(context.Context) error {
+			events = append(events, name)
+			return nil
+		}}
+	}
+	api := server.New(http.NotFoundHandler(), server.ServerConfig{Addr: "127.0.0.1:0", DrainDelay: 1},
+		server.WithHealth(reg), server.OnStart(hook("api start")), server.OnShutdown(hook("api shutdown")))
+	g := server.NewGroup(5*time.Second, server.WithSignals(), server.OnShutdown(hook("group shutdown"))).Add("api", api)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- g.Run(ctx) }()
+	<-api.Ready()
+	begin := time.Now()
+	cancel()
+
+	require.NoError(t, <-done)
+	assert.False(t, reg.Ready())
+	assert.GreaterOrEqual(t, time.Since(begin), time.Second, "the member's drain delay applies")
+	assert.Equal(t, []string{"api start", "api shutdown", "group shutdown"}, events)
+}
+
+func TestServer_Group_ShutdownBudget(t *testing.T) {
+	g := server.NewGroup(100*time.Millisecond, server.WithSignals()).
+		Go("stubborn", func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		}).
+		Go("polite", func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := g.Run(ctx)
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "stubborn: runner did not stop")
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestServer_Hooks_Order(t *testing.T) {
+	port := freePort(t)
+	url := "http://127.0.0.1:" + strconv.Itoa(port)
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) {
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	}
+	hook := func(name string, fn func()) server.Hook {
+		return server.Hook{Name: name, Fn: func(ctx context.Context) error { fn(); return nil }}
+	}
+	drained := make(chan bool, 1)
+
+	srv := server.New(http.NotFoundHandler(), server.ServerConfig{Port: port, ShutdownWait: 5},
+		server.WithSignals(),
+		server.WithDrainDelay(200*time.Millisecond),
+		server.OnStart(hook("db", func() { record("open db") }), hook("cache", func() { record("open cache") })),
+		server.BeforeShutdown(hook("readiness", func() {
+			record("not ready")
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				resp, err := http.Get(url)
+				if err == nil {
+					resp.Body.Close()
+				}
+				drained <- err == nil
+			}()
+		})),
+		server.OnShutdown(hook("db", func() {
+			_, err := net.Dial("tcp", "127.0.0.1:"+strconv.Itoa(port))
+			record("close db, server stopped: " + strconv.FormatBool(err != nil))
+		}), hook("cache", func() { record("close cache") })),
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- srv.Run(ctx) }()
+	resp := waitForServer(t, url)
+	resp.Body.Close()
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.True(t, <-drained, "server should keep serving during the drain delay")
+	assert.Equal(t, []string{"open db", "open cache", "not ready", "close cache", "close db, server stopped: true"}, events)
+}
+
+func TestServer_Hooks_Errors(t *testing.T) {
+	closed := false
+	g := server.NewGroup(time.Second,
+		server.WithSignals(),
+		server.OnStart(server.Hook{Name: "migrate", Fn: func(ctx context.Context) error {
+			return stderrors.New("schema locked")
+		}}),
+		server.OnShutdown(
+			server.Hook{Name: "flush", Timeout: 50 * time.Millisecond, Fn: func(ctx context.Context) error {
+				time.Sleep(time.Second)
+				return nil
+			}},
+			server.Hook{Name: "db", Fn: func(ctx context.Context) error {
+				closed = true
+				return nil
+			}},
+		),
+	).Go("worker", func(ctx context.Context) error {
+		t.Error("worker must not start when an OnStart hook fails")
+		return nil
+	})
+
+	err := g.Run(context.Background())
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `start hook "migrate": schema locked`)
+	assert.Contains(t, err.Error(), `shutdown hook "flush": context deadline exceeded`)
+	assert.True(t, closed)
+}
+
+type testPKI struct {
+	caCert *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	caPEM  []byte
+	dir    string
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testPKI{
+		caCert: cert,
+		caKey:  key,
+		caPEM:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		dir:    t.TempDir(),
+	}
+}
+
+// issue signs a leaf certificate for cn and returns it PEM encoded with its key.
+func (p *testPKI) issue(t *testing.T, cn string, serial int64, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, p.caCert, &key.PublicKey, p.caKey)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func (p *testPKI) writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+
+	path := filepath.Join(p.dir, name)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func (p *testPKI) client(clientCert *tls.Certificate, maxVersion uint16) *http.Client {
+	roots := x509.NewCertPool()
+	roots.AddCert(p.caCert)
+	cfg := &tls.Config{RootCAs: roots, MaxVersion: maxVersion}
+	if clientCert != nil {
+		cfg.Certificates = []tls.Certificate{*clientCert}
+	}
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: cfg, DisableKeepAlives: true, ForceAttemptHTTP2: true}}
+}
+
+func runTLSServer(t *testing.T, cfg server.ServerConfig) string {
+	t.Helper()
+
+	cfg.Port = freePort(t)
+	cfg.ShutdownWait = 5
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) { rw.Write([]byte(r.Proto)) })
+	go func() { done <- server.New(handler, cfg, server.WithSignals()).Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+
+	addr := "127.0.0.1:" + strconv.Itoa(cfg.Port)
+	for i := 0; i < 100; i++ {
+		if conn, err := net.Dial("tcp", addr); err == nil {
+			conn.Close()
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return "https://" + addr
+}
+
+func servedSerial(t *testing.T, client *http.Client, url string) int64 {
+	t.Helper()
+
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	return resp.TLS.PeerCertificates[0].SerialNumber.Int64()
+}
+
+func TestServer_TLS_HotReload(t *testing.T) {
+	pki := newTestPKI(t)
+	certPEM, keyPEM := pki.issue(t, "server", 10, x509.ExtKeyUsageServerAuth)
+	cfg := server.ServerConfig{
+		CertFile: pki.writeFile(t, "tls.crt", certPEM),
+		KeyFile:  pki.writeFile(t, "tls.key", keyPEM),
+	}
+	url := runTLSServer(t, cfg)
+	client := pki.client(nil, 0)
+
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "HTTP/2.0", string(body))
+	assert.Equal(t, int64(10), resp.TLS.PeerCertificates[0].SerialNumber.Int64())
+
+	certPEM, keyPEM = pki.issue(t, "server", 11, x509.ExtKeyUsageServerAuth)
+	pki.writeFile(t, "tls.key", keyPEM)
+	pki.writeFile(t, "tls.crt", certPEM)
+	assert.Eventually(t, func() bool { return servedSerial(t, client, url) == 11 }, 5*time.Second, 100*time.Millisecond)
+
+	pki.writeFile(t, "tls.crt", []byte("not a certificate"))
+	time.Sleep(1500 * time.Millisecond) // past the reloader's check interval
+	assert.Equal(t, int64(11), servedSerial(t, client, url), "a broken rotation keeps the previous certificate")
+}
+
+func TestServer_TLS_Mutual(t *testing.T) {
+	pki := newTestPKI(t)
+	certPEM, keyPEM := pki.issue(t, "server", 10, x509.ExtKeyUsageServerAuth)
+	url := runTLSServer(t, server.ServerConfig{
+		CertFile:     pki.writeFile(t, "tls.crt", certPEM),
+		KeyFile:      pki.writeFile(t, "tls.key", keyPEM),
+		ClientCAFile: pki.writeFile(t, "ca.crt", pki.caPEM),
+	})
+
+	_, err := pki.client(nil, 0).Get(url)
+	assert.Error(t, err, "clients without a certificate are rejected")
+
+	clientPEM, clientKey := pki.issue(t, "client", 20, x509.ExtKeyUsageClientAuth)
+	clientCert, err := tls.X509KeyPair(clientPEM, clientKey)
+	require.NoError(t, err)
+	resp, err := pki.client(&clientCert, 0).Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestServer_TLS_Policy(t *testing.T) {
+	pki := newTestPKI(t)
+	certPEM, keyPEM := pki.issue(t, "server", 10, x509.ExtKeyUsageServerAuth)
+	cfg := server.ServerConfig{
+		CertFile:      pki.writeFile(t, "tls.crt", certPEM),
+		KeyFile:       pki.writeFile(t, "tls.key", keyPEM),
+		MinTLSVersion: "1.3",
+	}
+	url := runTLSServer(t, cfg)
+
+	_, err := pki.client(nil, tls.VersionTLS12).Get(url)
+	assert.Error(t, err, "TLS 1.2 clients are rejected")
+	resp, err := pki.client(nil, 0).Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	for _, bad := range []server.ServerConfig{
+		{CertFile: cfg.CertFile, KeyFile: cfg.KeyFile, MinTLSVersion: "1.0"},
+		{CertFile: cfg.CertFile, KeyFile: cfg.KeyFile, CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}},
+		{CertFile: cfg.CertFile},
+		{CertFile: cfg.CertFile, KeyFile: cfg.CertFile},
+	} {
+		bad.Port = freePort(t)
+		assert.Error(t, server.New(http.NotFoundHandler(), bad, server.WithSignals()).Run(context.Background()))
+	}
+}
+
+func startServer(t *testing.T, srv *server.Server) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- srv.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+	select {
+	case <-srv.Ready():
+	case err := <-done:
+		t.Fatalf("server failed to start: %v", err)
+	}
+}
+
+func TestServer_Addr_EphemeralPort(t *testing.T) {
+	srv := server.New(http.NotFoundHandler(), server.ServerConfig{Addr: "127.0.0.1:0"}, server.WithSignals())
+	assert.Nil(t, srv.Addr())
+
+	startServer(t, srv)
+
+	addr := srv.Addr().(*net.TCPAddr)
+	assert.Equal(t, "127.0.0.1", addr.IP.String())
+	assert.NotZero(t, addr.Port)
+	resp, err := http.Get("http://" + addr.String())
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestServer_Addr_StartFailure(t *testing.T) {
+	srv := server.New(http.NotFoundHandler(), server.ServerConfig{Addr: "127.0.0.1:0", Protocols: []string{"spdy"}}, server.WithSignals())
+	done := make(chan error, 1)
+	go func() { done <- srv.Run(context.Background()) }()
+
+	select {
+	case <-srv.Ready():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Ready was not closed after a failed start")
+	}
+	require.Error(t, srv.Err())
+	assert.Nil(t, srv.Addr())
+	assert.Equal(t, srv.Err(), <-done)
+
+	ok := server.New(http.NotFoundHandler(), server.ServerConfig{Addr: "127.0.0.1:0"}, server.WithSignals())
+	startServer(t, ok)
+	assert.NoError(t, ok.Err())
+	assert.ErrorContains(t, ok.Run(context.Background()), "already started")
+}
+
+func TestServer_Addr_UnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.sock")
+	stale, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) { rw.Write([]byte("over unix")) })
+	srv := server.New(handler, server.ServerConfig{Network: "unix", Addr: path}, server.WithSignals())
+	startServer(t, srv)
+
+	assert.Equal(t, "unix", srv.Addr().Network())
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Get("http://unix/")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "over unix", string(body))
+}
+
+func TestServer_Addr_InjectedListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := server.New(http.NotFoundHandler(), server.ServerConfig{Listener: ln, Port: 1}, server.WithSignals())
+	startServer(t, srv)
+
+	assert.Equal(t, ln.Addr().String(), srv.Addr().String())
+	resp, err := http.Get("http://" + srv.Addr().String())
+	require.NoError(t, err)
+	resp.Body.Close()
+}
+
+// TestServer_Restart_Handoff runs a server in a helper copy of the test
+// binary, restarts it with SIGUSR2 and checks the replacement keeps
+// serving on the same port while the old process exits cleanly.
+func TestServer_Restart_Handoff(t *testing.T) {
+	if port := os.Getenv("RESTART_HELPER_PORT"); port != "" {
+		runRestartHelper(server.ServerConfig{Addr: "127.0.0.1:" + port})
+	}
+	testRestartHandoff(t, "TestServer_Restart_Handoff")
+}
+
+// TestServer_Restart_InjectedListener checks that the socket handed over by
+// a restart takes precedence over an injected ServerConfig.Listener.
+func TestServer_Restart_InjectedListener(t *testing.T) {
+	if port := os.Getenv("RESTART_HELPER_PORT"); port != "" {
+		// The restarted process cannot bind the port its parent still holds.
+		ln, err := net.Listen("tcp", "127.0.0.1:"+port)
+		if err != nil {
+			ln, _ = net.Listen("tcp", "127.0.0.1:0")
+		}
+		runRestartHelper(server.ServerConfig{Listener: ln})
+	}
+	testRestartHandoff(t, "TestServer_Restart_InjectedListener")
+}
+
+// runRestartHelper serves the process ID with restarts enabled and exits.
+func runRestartHelper(cfg server.ServerConfig) {
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte(strconv.Itoa(os.Getpid())))
+	})
+	cfg.ShutdownWait = 5
+	if err := server.New(handler, cfg, server.WithRestart()).Run(context.Background()); err != nil {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func testRestartHandoff(t *testing.T, name string) {
+	t.Helper()
+
+	port := freePort(t)
+	url := "http://127.0.0.1:" + strconv.Itoa(port)
+	cmd := exec.Command(os.Args[0], "-test.run=^"+name+"$")
+	cmd.Env = append(os.Environ(), "RESTART_HELPER_PORT="+strconv.Itoa(port))
+	cmd.Stderr = os.Stderr
+	require.NoError(t, cmd.Start())
+	t.Cleanup(func() { cmd.Process.Kill() })
+
+	servedBy := func() int {
+		resp := waitForServer(t, url)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		pid, _ := strconv.Atoi(string(body))
+		return pid
+	}
+	require.Equal(t, cmd.Process.Pid, servedBy())
+
+	require.NoError(t, cmd.Process.Signal(syscall.SIGUSR2))
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	select {
+	case err := <-exited:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("old process did not exit after restart")
+	}
+
+	child := servedBy()
+	assert.NotEqual(t, cmd.Process.Pid, child)
+	require.NoError(t, syscall.Kill(child, syscall.SIGTERM))
+}
+
+func TestServer_Protocols_H2C(t *testing.T) {
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) { rw.Write([]byte(r.Proto)) })
+	srv := server.New(handler, server.ServerConfig{
+		Addr:                 "127.0.0.1:0",
+		Protocols:            []string{"http1", "h2c"},
+		MaxConcurrentStreams: 10,
+		ShutdownWait:         5,
+	}, server.WithSignals())
+	startServer(t, srv)
+	url := "http://" + srv.Addr().String()
+
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	for proto, transport := range map[string]*http.Transport{
+		"HTTP/2.0": {Protocols: protocols},
+		"HTTP/1.1": {},
+	} {
+		defer transport.CloseIdleConnections()
+		resp, err := (&http.Client{Transport: transport}).Get(url)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, proto, string(body))
+	}
+}
+
+func TestServer_Protocols_HTTP3(t *testing.T) {
+	pki := newTestPKI(t)
+	certPEM, keyPEM := pki.issue(t, "server", 10, x509.ExtKeyUsageServerAuth)
+	url := runTLSServer(t, server.ServerConfig{
+		CertFile: pki.writeFile(t, "tls.crt", certPEM),
+		KeyFile:  pki.writeFile(t, "tls.key", keyPEM),
+		HTTP3:    true,
+	})
+
+	resp, err := pki.client(nil, 0).Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Contains(t, resp.Header.Get("Alt-Svc"), `h3=":`+resp.Request.URL.Port()+`"`)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(pki.caCert)
+	transport := &http3.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}
+	defer transport.Close()
+	resp, err = (&http.Client{Transport: transport}).Get(url)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "HTTP/3.0", string(body))
+}
+
+func TestServer_Protocols_Invalid(t *testing.T) {
+	for _, bad := range []server.ServerConfig{
+		{Protocols: []string{"spdy"}},
+		{HTTP3: true},
+		{MaxHeaderBytes: -1},
+	} {
+		bad.Addr = "127.0.0.1:0"
+		assert.Error(t, server.New(http.NotFoundHandler(), bad, server.WithSignals()).Run(context.Background()))
+	}
+}
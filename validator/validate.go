@@ -1,6 +1,7 @@
 package validate
 
 import (
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
@@ -25,6 +26,7 @@ func init() {
 
 func InitValidator() *validator.Validate {
 	validate = validator.New()
+	validate.RegisterTagNameFunc(jsonFieldName)
 
 	enLocale := en.New()
 	uni := ut.New(enLocale, enLocale)
@@ -67,11 +69,36 @@ func InitValidator() *validator.Validate {
 
 func FormatValidationErrors(err error) *errors.AppError {
 	var validationErrors []string
+	var fieldErrors []error
 	for _, err := range err.(validator.ValidationErrors) {
-		validationErrors = append(validationErrors, err.Translate(trans))
+		msg := err.Translate(trans)
+		validationErrors = append(validationErrors, msg)
+		reason := strings.TrimPrefix(msg, err.Field()+" ")
+		fieldErrors = append(fieldErrors, errors.FieldErrorf(fieldPath(err), "%s", reason))
 	}
 	errorMsg := strings.Join(validationErrors, ", ")
-	return errors.BadRequest(errorMsg)
+	appErr := errors.BadRequest(errorMsg)
+	appErr.Cause = &errors.ErrorList{Errors: fieldErrors}
+	return appErr
+}
+
+// jsonFieldName names fields after their json tag so errors refer to the
+// request body members. Untagged fields keep their Go name.
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// fieldPath returns the dotted path of the invalid field below the
+// validated struct, e.g. "address.city".
+func fieldPath(fe validator.FieldError) string {
+	if _, path, ok := strings.Cut(fe.Namespace(), "."); ok {
+		return path
+	}
+	return fe.Field()
 }
 
 func validatePassword(fl validator.FieldLevel) bool {